@@ -0,0 +1,21 @@
+package main
+
+// Twice the area enclosed by the loop, by the shoelace formula
+func getDoubleArea(loop []Coord) int {
+	acc := 0
+	for i, curr := range loop {
+		next := loop[(i+1)%len(loop)]
+		acc += curr.x*next.y - next.x*curr.y
+	}
+	return max(acc, -acc)
+}
+
+// Pick's theorem, A = I + B/2 - 1, with the loop tiles as the boundary
+// points B. Solving for the interior points gives I = (2A - B + 2) / 2
+func solveBShoelace(grid Grid) int {
+	loop := getMainLoop(grid)
+	if len(loop) == 0 {
+		return 0
+	}
+	return (getDoubleArea(loop) - len(loop) + 2) / 2
+}
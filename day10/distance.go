@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Distances along the main loop. The loop starts at S, so a tile's index
+// is its distance from S going one way round, and len(loop) minus the
+// index is its distance going the other way
+type LoopDistances struct {
+	loop  []Coord
+	index map[Coord]int
+}
+
+func getLoopDistances(loop []Coord) LoopDistances {
+	index := make(map[Coord]int, len(loop))
+	for i, c := range loop {
+		index[c] = i
+	}
+	return LoopDistances{loop, index}
+}
+
+func (d LoopDistances) Forward(c Coord) (int, bool) {
+	i, ok := d.index[c]
+	return i, ok
+}
+
+func (d LoopDistances) Backward(c Coord) (int, bool) {
+	i, ok := d.index[c]
+	if !ok {
+		return 0, false
+	}
+	return (len(d.loop) - i) % len(d.loop), true
+}
+
+// Shortest distance between two loop tiles, going either way round
+func (d LoopDistances) Between(a, b Coord) (int, bool) {
+	ia, okA := d.index[a]
+	ib, okB := d.index[b]
+	if !okA || !okB {
+		return 0, false
+	}
+	steps := (ib - ia + len(d.loop)) % len(d.loop)
+	return min(steps, len(d.loop)-steps), true
+}
+
+func (d LoopDistances) FromStart(c Coord) (int, bool) {
+	if len(d.loop) == 0 {
+		return 0, false
+	}
+	return d.Between(d.loop[0], c)
+}
+
+// One tile for a loop of even length, two for an odd one
+func (d LoopDistances) Farthest() []Coord {
+	n := len(d.loop)
+	if n == 0 {
+		return nil
+	}
+	if n%2 == 0 {
+		return []Coord{d.loop[n/2]}
+	}
+	return []Coord{d.loop[n/2], d.loop[n/2+1]}
+}
+
+// Tiles from a to b, both included, along the shorter way round
+func (d LoopDistances) SubPath(a, b Coord) ([]Coord, bool) {
+	ia, okA := d.index[a]
+	ib, okB := d.index[b]
+	if !okA || !okB {
+		return nil, false
+	}
+
+	n := len(d.loop)
+	step := 1
+	if (ib-ia+n)%n > n/2 {
+		step = -1
+	}
+
+	path := []Coord{a}
+	for i := ia; i != ib; {
+		i = (i + step + n) % n
+		path = append(path, d.loop[i])
+	}
+	return path, true
+}
+
+var heatColors = []int{21, 27, 33, 39, 45, 50, 82, 190, 208, 196}
+
+// Distance from S in tenths of the farthest distance, coloured from blue
+// to red
+func (d LoopDistances) Heatmap(grid Grid) string {
+	farthest := len(d.loop) / 2
+
+	var buff strings.Builder
+	for y := range grid {
+		for x := range grid[y] {
+			dist, ok := d.FromStart(Coord{x, y})
+			if !ok {
+				buff.WriteString(ansiDim + "." + ansiReset)
+				continue
+			}
+			level := min(dist*10/(farthest+1), 9)
+			fmt.Fprintf(&buff, "\033[38;5;%dm%d"+ansiReset, heatColors[level], level)
+		}
+		buff.WriteString("\n")
+	}
+	return buff.String()
+}
+
+type tileDistance struct {
+	X        int `json:"x"`
+	Y        int `json:"y"`
+	Forward  int `json:"forward"`
+	Backward int `json:"backward"`
+	Distance int `json:"distance"`
+}
+
+func (d LoopDistances) WriteJSON(w io.Writer) error {
+	tiles := make([]tileDistance, len(d.loop))
+	for i, c := range d.loop {
+		backward, _ := d.Backward(c)
+		tiles[i] = tileDistance{c.x, c.y, i, backward, min(i, backward)}
+	}
+
+	farthest := [][2]int{}
+	for _, c := range d.Farthest() {
+		farthest = append(farthest, [2]int{c.x, c.y})
+	}
+
+	enc := json.NewEncoder(w)
+	return enc.Encode(struct {
+		Length   int            `json:"length"`
+		Farthest [][2]int       `json:"farthest"`
+		Tiles    []tileDistance `json:"tiles"`
+	}{len(d.loop), farthest, tiles})
+}
+
+// Parses "x1,y1:x2,y2"
+func parseQuery(query string) (a, b Coord, err error) {
+	_, err = fmt.Sscanf(query, "%d,%d:%d,%d", &a.x, &a.y, &b.x, &b.y)
+	return a, b, err
+}
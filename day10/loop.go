@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	ProblemNoStart        = iota
+	ProblemMultipleStarts = iota
+	ProblemDeadEnd        = iota
+	ProblemBranch         = iota
+	ProblemOffGrid        = iota
+	ProblemNoLoop         = iota
+)
+
+type LoopProblem struct {
+	kind   int
+	at     Coord
+	detail string
+}
+
+func (p LoopProblem) String() string {
+	kinds := []string{"no start", "multiple starts", "dead end", "branch", "off grid", "no loop"}
+	return fmt.Sprintf("%s at (%d, %d): %s", kinds[p.kind], p.at.x, p.at.y, p.detail)
+}
+
+type LoopResult struct {
+	start     Coord
+	loop      []Coord
+	startType Pipe
+	problems  []LoopProblem
+}
+
+func getStartCoords(grid Grid) (starts []Coord) {
+	for y, row := range grid {
+		for x, char := range row {
+			if charToPipe(char) == Start {
+				starts = append(starts, Coord{x, y})
+			}
+		}
+	}
+	return starts
+}
+
+// Follows the pipes from start through first until it gets back to start.
+// Fails on the first tile that doesn't continue the path
+func walkLoop(start, first Coord, grid Grid) ([]Coord, *LoopProblem) {
+	loop := []Coord{start}
+	seen := map[Coord]bool{start: true}
+	prev, curr := start, first
+
+	// A loop can't be longer than the grid, so this always terminates
+	for {
+		if curr == start {
+			return loop, nil
+		}
+		if !isValidCoord(curr, grid) {
+			return nil, &LoopProblem{ProblemOffGrid, prev, fmt.Sprintf("pipe leads to (%d, %d)", curr.x, curr.y)}
+		}
+		if seen[curr] {
+			return nil, &LoopProblem{ProblemBranch, curr, "path runs back into itself"}
+		}
+
+		char := grid[curr.y][curr.x]
+		nexts := getNeighbours(curr, charToPipe(char))
+		if !slices.Contains(nexts, prev) {
+			return nil, &LoopProblem{ProblemDeadEnd, curr, fmt.Sprintf("%c does not connect back to (%d, %d)", char, prev.x, prev.y)}
+		}
+
+		seen[curr] = true
+		loop = append(loop, curr)
+
+		next := nexts[0]
+		if next == prev {
+			next = nexts[1]
+		}
+		prev, curr = curr, next
+	}
+}
+
+// Finds the main loop through the first start tile, along with every
+// problem seen on the way. When more than two pipes connect to the start,
+// each of them is tried and the longest closed loop wins
+func extractLoop(grid Grid) (res LoopResult) {
+	res.startType = Start
+
+	starts := getStartCoords(grid)
+	if len(starts) == 0 {
+		res.problems = append(res.problems, LoopProblem{ProblemNoStart, Coord{-1, -1}, "grid has no S tile"})
+		return res
+	}
+	for _, extra := range starts[1:] {
+		res.problems = append(res.problems, LoopProblem{ProblemMultipleStarts, extra, fmt.Sprintf("ignored, using (%d, %d)", starts[0].x, starts[0].y)})
+	}
+	res.start = starts[0]
+
+	neighbors := getStartingNeighbors(res.start, grid)
+	switch {
+	case len(neighbors) < 2:
+		res.problems = append(res.problems, LoopProblem{ProblemDeadEnd, res.start, fmt.Sprintf("only %d pipes connect to S", len(neighbors))})
+	case len(neighbors) > 2:
+		res.problems = append(res.problems, LoopProblem{ProblemBranch, res.start, fmt.Sprintf("%d pipes connect to S", len(neighbors))})
+	}
+
+	for _, n := range neighbors {
+		loop, problem := walkLoop(res.start, n, grid)
+		if problem != nil {
+			// Both ways round can run into the same broken tile
+			seen := slices.ContainsFunc(res.problems, func(p LoopProblem) bool {
+				return p.kind == problem.kind && p.at == problem.at
+			})
+			if !seen {
+				res.problems = append(res.problems, *problem)
+			}
+			continue
+		}
+		if len(loop) > len(res.loop) {
+			res.loop = loop
+		}
+	}
+
+	if len(res.loop) >= 2 {
+		res.startType = getStartType(res.start, res.loop[1], res.loop[len(res.loop)-1])
+	} else {
+		res.loop = nil
+		res.problems = append(res.problems, LoopProblem{ProblemNoLoop, res.start, "no closed loop through S"})
+		// Best guess from the pipes pointing at S
+		if len(neighbors) >= 2 {
+			res.startType = getStartType(res.start, neighbors[0], neighbors[1])
+		}
+	}
+
+	return res
+}
+
+func getMainLoop(grid Grid) []Coord {
+	return extractLoop(grid).loop
+}
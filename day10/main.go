@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"os"
 )
@@ -130,18 +131,6 @@ func parseInput(filename string) Grid {
 	return lines
 }
 
-func getStartCoord(grid Grid) Coord {
-	for y, row := range grid {
-		for x, char := range row {
-			if charToPipe(char) == Start {
-				return Coord{x, y}
-			}
-		}
-	}
-
-	panic("No start found")
-}
-
 func isValidCoord(coord Coord, grid Grid) bool {
 	x, y := coord.x, coord.y
 
@@ -155,16 +144,6 @@ func isValidCoord(coord Coord, grid Grid) bool {
 	return true
 }
 
-func getNextCoord(curr Coord, nexts []Coord, grid Grid) Coord {
-	for _, n := range nexts {
-		if n != curr && isValidCoord(n, grid) {
-			return n
-		}
-	}
-
-	return Coord{-1, -1}
-}
-
 func getStartingNeighbors(start Coord, grid Grid) []Coord {
 	x, y := start.x, start.y
 	potentialNeighbors := []Coord{
@@ -194,56 +173,11 @@ func getStartingNeighbors(start Coord, grid Grid) []Coord {
 	return neighbors
 }
 
-func getLoop(start Coord, next Coord, grid Grid) []Coord {
-	loop := make([]Coord, 1)
-	loop[0] = start
-
-	curr := start
-
-	// fmt.Println("Curr\tNext\tNeighbors")
-	// for i := 0; i < 3; i++ {
-	for {
-		pipe := charToPipe(grid[next.y][next.x])
-		allN := getNeighbours(next, pipe)
-		// fmt.Printf("%v\t%v\t%v\n", curr, next, allN)
-
-		temp := next
-		next = getNextCoord(curr, allN, grid)
-		curr = temp
-
-		// Loop is broken
-		if !isValidCoord(curr, grid) {
-			return []Coord{}
-		}
-
-		// The loop is complete
-		if curr == start {
-			return loop
-		}
-
-		loop = append(loop, curr)
-	}
-}
-
-func getMainLoop(grid Grid) []Coord {
-	startCoord := getStartCoord(grid)
-
-	startNeighbors := getStartingNeighbors(startCoord, grid)
-
-	var mainLoop []Coord
-	for _, n := range startNeighbors {
-		l := getLoop(startCoord, n, grid)
-		if len(mainLoop) < len(l) {
-			mainLoop = l
-		}
-	}
-
-	return mainLoop
-}
-
 func solveA(grid Grid) (solnA int) {
-	mainLoop := getMainLoop(grid)
-	solnA = len(mainLoop) / 2
+	distances := getLoopDistances(getMainLoop(grid))
+	if farthest := distances.Farthest(); len(farthest) > 0 {
+		solnA, _ = distances.FromStart(farthest[0])
+	}
 	return
 }
 
@@ -277,18 +211,12 @@ func getStartType(start, n1, n2 Coord) Pipe {
 	}
 }
 
-func replaceStart(grid *Grid, loop []Coord) *Grid {
-	// Figure out the type of start
-	// The loop will always have start as the first coord
-	startType := getStartType(loop[0], loop[1], loop[len(loop)-1])
-
-	for y, row := range *grid {
-		for x, char := range row {
-			if charToPipe(char) == Start {
-				(*grid)[y][x] = pipeToChar(startType)
-			}
-		}
+// Only the start in use is replaced, any other S is left as it is
+func replaceStart(grid *Grid, start Coord, startType Pipe) *Grid {
+	if !isValidCoord(start, *grid) {
+		return grid
 	}
+	(*grid)[start.y][start.x] = pipeToChar(startType)
 	return grid
 }
 
@@ -356,8 +284,9 @@ func isCoordInLoop(coord Coord, loop map[Coord]bool, grid Grid) bool {
 }
 
 func solveB(grid Grid) (solnB int) {
-	loop := getMainLoop(grid)
-	replaceStart(&grid, loop)
+	res := extractLoop(grid)
+	loop := res.loop
+	replaceStart(&grid, res.start, res.startType)
 
 	// Convert loop to a map for faster lookups
 	loopMap := make(map[Coord]bool, len(loop))
@@ -376,25 +305,119 @@ func solveB(grid Grid) (solnB int) {
 	return
 }
 
+var inputFile = flag.String("input", "day10/in.txt", "puzzle input")
+var mode = flag.String("mode", "ray", "how to count the enclosed tiles: ray or shoelace")
+var render = flag.Bool("render", false, "draw the loop and the enclosed tiles in the terminal")
+var svgFile = flag.String("svg", "", "draw the loop and the enclosed tiles as SVG")
+var heatmap = flag.Bool("heatmap", false, "print the distance of every loop tile from the start")
+var distanceFile = flag.String("distances", "", "write the distance of every loop tile as JSON")
+var query = flag.String("query", "", "print the distance and path between two loop tiles, as x1,y1:x2,y2")
+var check = flag.Bool("check", false, "compare both counting modes on the examples and exit")
+
+var exampleFiles = []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+// Ray casting and the shoelace formula must agree on every example
+func checkModes() bool {
+	ok := true
+	for _, name := range exampleFiles {
+		filename := "day10/" + name + ".txt"
+		ray := solveB(parseInput(filename))
+		shoelace := solveBShoelace(parseInput(filename))
+
+		status := "ok"
+		if ray != shoelace {
+			status = "MISMATCH"
+			ok = false
+		}
+		fmt.Printf("%s\tray %d\tshoelace %d\t%s\n", filename, ray, shoelace, status)
+	}
+	return ok
+}
+
 func main() {
-	grid := parseInput("day10/in.txt")
+	flag.Parse()
 
-	// Preview for examples
-	if len(grid[0]) < 80 {
-		for _, line := range grid {
-			for _, char := range line {
-				pipe := charToPipe(char)
-				displayChar := pipeToDisplayChar(pipe)
-				fmt.Print(string(displayChar))
-			}
-			fmt.Println()
+	if *check {
+		if !checkModes() {
+			os.Exit(1)
 		}
+		return
+	}
+
+	grid := parseInput(*inputFile)
+
+	res := extractLoop(grid)
+	for _, problem := range res.problems {
+		fmt.Println("Problem:", problem)
+	}
+	if len(res.problems) > 0 {
+		fmt.Printf("Start: (%d, %d) is %c\n", res.start.x, res.start.y, pipeToDisplayChar(res.startType))
+	}
+	if len(res.loop) == 0 {
+		os.Exit(1)
+	}
+
+	// Preview for examples
+	if len(grid[0]) < 80 || *render {
+		renderTerminal(os.Stdout, getTileMap(grid))
 		fmt.Println()
 	}
 
+	distances := getLoopDistances(res.loop)
+	if *heatmap {
+		fmt.Println(distances.Heatmap(grid))
+	}
+
+	if *distanceFile != "" {
+		f, err := os.Create(*distanceFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := distances.WriteJSON(f); err != nil {
+			fmt.Println(err)
+		}
+		f.Close()
+	}
+
+	if *query != "" {
+		a, b, err := parseQuery(*query)
+		if err != nil {
+			fmt.Println("bad query:", err)
+			os.Exit(1)
+		}
+		path, ok := distances.SubPath(a, b)
+		if !ok {
+			fmt.Println("both tiles must be on the loop")
+			os.Exit(1)
+		}
+		dist, _ := distances.Between(a, b)
+		fmt.Println("Distance:", dist)
+		fmt.Println("Path:", path)
+	}
+
+	if *svgFile != "" {
+		f, err := os.Create(*svgFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		renderSVG(f, getTileMap(grid))
+		f.Close()
+	}
+
 	// solnA := solveA(grid)
 	// fmt.Println("Solution A:", solnA)
 
-	solnB := solveB(grid)
+	var solnB int
+	switch *mode {
+	case "ray":
+		solnB = solveB(grid)
+	case "shoelace":
+		solnB = solveBShoelace(grid)
+	default:
+		fmt.Println("unknown mode:", *mode)
+		os.Exit(1)
+	}
 	fmt.Println("Solution B:", solnB)
 }
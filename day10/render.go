@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	ansiReset    = "\033[0m"
+	ansiDim      = "\033[2m"
+	ansiBold     = "\033[1m"
+	ansiRed      = "\033[31m"
+	ansiGreen    = "\033[32m"
+	ansiBlue     = "\033[34m"
+	ansiYellowBg = "\033[43m"
+)
+
+type TileMap struct {
+	grid     Grid
+	loop     []Coord
+	onLoop   map[Coord]bool
+	interior map[Coord]bool
+	farthest Coord
+}
+
+// Works on a copy of the grid, since the start tile gets replaced by its
+// actual pipe to count the interior
+func getTileMap(grid Grid) TileMap {
+	grid = slices.Clone(grid)
+	for y := range grid {
+		grid[y] = slices.Clone(grid[y])
+	}
+
+	tiles := TileMap{grid: grid, onLoop: map[Coord]bool{}, interior: map[Coord]bool{}}
+	res := extractLoop(grid)
+	tiles.loop = res.loop
+	if len(tiles.loop) == 0 {
+		return tiles
+	}
+	replaceStart(&grid, res.start, res.startType)
+
+	for _, c := range tiles.loop {
+		tiles.onLoop[c] = true
+	}
+	for y := range grid {
+		for x := range grid[y] {
+			if isCoordInLoop(Coord{x, y}, tiles.onLoop, grid) {
+				tiles.interior[Coord{x, y}] = true
+			}
+		}
+	}
+	tiles.farthest = getLoopDistances(tiles.loop).Farthest()[0]
+
+	return tiles
+}
+
+// Loop pipes in bold box-drawing characters, the farthest point of the
+// loop highlighted, and everything else green inside the loop and blue
+// outside of it. Pipes that are not on the loop are dimmed on both sides,
+// like the exterior ground
+func renderTerminal(w io.Writer, tiles TileMap) {
+	for y, row := range tiles.grid {
+		var line strings.Builder
+		for x, char := range row {
+			coord := Coord{x, y}
+			pipe := charToPipe(char)
+
+			switch {
+			case coord == tiles.farthest && len(tiles.loop) > 0:
+				line.WriteString(ansiBold + ansiRed + ansiYellowBg + string(pipeToDisplayChar(pipe)) + ansiReset)
+			case tiles.onLoop[coord]:
+				line.WriteString(ansiBold + string(pipeToDisplayChar(pipe)) + ansiReset)
+			case pipe != Ground && pipe != Invalid:
+				colour := ansiBlue
+				if tiles.interior[coord] {
+					colour = ansiGreen
+				}
+				line.WriteString(ansiDim + colour + string(pipeToDisplayChar(pipe)) + ansiReset)
+			case tiles.interior[coord]:
+				line.WriteString(ansiGreen + string(pipeToDisplayChar(pipe)) + ansiReset)
+			default:
+				line.WriteString(ansiDim + ansiBlue + string(pipeToDisplayChar(pipe)) + ansiReset)
+			}
+		}
+		fmt.Fprintln(w, line.String())
+	}
+}
+
+// One unit square per tile: interior and exterior as filled squares, the
+// loop as a single closed path through the tile centres
+func renderSVG(w io.Writer, tiles TileMap) {
+	height := len(tiles.grid)
+	width := 0
+	if height > 0 {
+		width = len(tiles.grid[0])
+	}
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`+"\n",
+		width, height, width*8, height*8)
+	fmt.Fprintf(w, `<rect width="%d" height="%d" fill="#dde6f0"/>`+"\n", width, height)
+
+	fmt.Fprintln(w, `<g fill="#5cb85c">`)
+	for y := range tiles.grid {
+		for x := range tiles.grid[y] {
+			if tiles.interior[Coord{x, y}] {
+				fmt.Fprintf(w, `<rect x="%d" y="%d" width="1" height="1"/>`+"\n", x, y)
+			}
+		}
+	}
+	fmt.Fprintln(w, `</g>`)
+
+	if len(tiles.loop) > 0 {
+		var path strings.Builder
+		for i, c := range tiles.loop {
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			fmt.Fprintf(&path, "%s%d.5 %d.5 ", cmd, c.x, c.y)
+		}
+		path.WriteString("Z")
+
+		fmt.Fprintf(w, `<path d="%s" fill="none" stroke="#222" stroke-width="0.3" stroke-linejoin="round"/>`+"\n", path.String())
+		fmt.Fprintf(w, `<circle cx="%d.5" cy="%d.5" r="0.6" fill="#d9534f"/>`+"\n", tiles.farthest.x, tiles.farthest.y)
+	}
+
+	fmt.Fprintln(w, `</svg>`)
+}
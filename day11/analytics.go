@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+const (
+	MetricManhattan = iota
+	MetricChebyshev = iota
+	MetricEuclidean = iota
+)
+
+var metricNames = []string{"manhattan", "chebyshev", "euclidean"}
+
+func getMetric(name string) (int, bool) {
+	idx := slices.Index(metricNames, name)
+	return idx, idx >= 0
+}
+
+// Manhattan and Chebyshev distances are exact, only Euclidean is rounded
+func distance(a, b [2]*big.Int, metric int) *big.Float {
+	dx := new(big.Int).Sub(a[0], b[0])
+	dx.Abs(dx)
+	dy := new(big.Int).Sub(a[1], b[1])
+	dy.Abs(dy)
+
+	d := new(big.Int)
+	switch metric {
+	case MetricChebyshev:
+		if dx.Cmp(dy) > 0 {
+			d.Set(dx)
+		} else {
+			d.Set(dy)
+		}
+	case MetricEuclidean:
+		d.Add(dx.Mul(dx, dx), dy.Mul(dy, dy))
+		sq := new(big.Float).SetInt(d)
+		return sq.Sqrt(sq).SetPrec(53)
+	default:
+		d.Add(dx, dy)
+	}
+	return new(big.Float).SetInt(d)
+}
+
+type Universe struct {
+	galaxies  []Coord
+	positions [][2]*big.Int
+	metric    int
+}
+
+func getUniverse(grid Grid, factor int64, metric int) Universe {
+	galaxies := findGalaxies(grid)
+	exp := getExpansion(grid)
+
+	positions := make([][2]*big.Int, len(galaxies))
+	for i, g := range galaxies {
+		x, y := exp.expand(g, factor)
+		positions[i] = [2]*big.Int{x, y}
+	}
+
+	return Universe{galaxies, positions, metric}
+}
+
+func (u Universe) dist(i, j int) *big.Float {
+	return distance(u.positions[i], u.positions[j], u.metric)
+}
+
+type Neighbour struct {
+	galaxy int
+	dist   *big.Float
+}
+
+// The k galaxies closest to galaxy i, closest first. A negative k is
+// the same as 0
+func (u Universe) Nearest(i, k int) []Neighbour {
+	neighbours := make([]Neighbour, 0, max(len(u.galaxies)-1, 0))
+	for j := range u.galaxies {
+		if j != i {
+			neighbours = append(neighbours, Neighbour{j, u.dist(i, j)})
+		}
+	}
+
+	slices.SortFunc(neighbours, func(a, b Neighbour) int {
+		if c := a.dist.Cmp(b.dist); c != 0 {
+			return c
+		}
+		return a.galaxy - b.galaxy
+	})
+
+	return neighbours[:min(max(k, 0), len(neighbours))]
+}
+
+func (u Universe) FarthestPair() (a, b int, dist *big.Float) {
+	a, b, dist = -1, -1, big.NewFloat(-1)
+	for i := range u.galaxies {
+		for j := i + 1; j < len(u.galaxies); j++ {
+			if d := u.dist(i, j); d.Cmp(dist) > 0 {
+				a, b, dist = i, j, d
+			}
+		}
+	}
+	return a, b, dist
+}
+
+type Histogram struct {
+	lo, width float64
+	counts    []int
+}
+
+// Pairwise distances in equal width bins between the smallest and the
+// largest distance. Bins are only for display, so float64 is plenty
+func (u Universe) Histogram(bins int) Histogram {
+	dists := []float64{}
+	for i := range u.galaxies {
+		for j := i + 1; j < len(u.galaxies); j++ {
+			d, _ := u.dist(i, j).Float64()
+			dists = append(dists, d)
+		}
+	}
+
+	h := Histogram{counts: make([]int, bins)}
+	if len(dists) == 0 {
+		return h
+	}
+
+	lo, hi := slices.Min(dists), slices.Max(dists)
+	h.lo, h.width = lo, max((hi-lo)/float64(bins), 1)
+	for _, d := range dists {
+		h.counts[min(int((d-lo)/h.width), bins-1)]++
+	}
+	return h
+}
+
+func (h Histogram) String() string {
+	most := slices.Max(h.counts)
+
+	var buff strings.Builder
+	for i, count := range h.counts {
+		bar := 0
+		if most > 0 {
+			bar = count * 50 / most
+		}
+		lo := h.lo + float64(i)*h.width
+		fmt.Fprintf(&buff, "%12.1f - %-12.1f %8d %s\n", lo, lo+h.width, count, strings.Repeat("#", bar))
+	}
+	return buff.String()
+}
+
+func (u Universe) Report(k, bins int) string {
+	var buff strings.Builder
+
+	fmt.Fprintf(&buff, "Nearest %d (%s):\n", k, metricNames[u.metric])
+	for i, g := range u.galaxies {
+		parts := []string{}
+		for _, n := range u.Nearest(i, k) {
+			other := u.galaxies[n.galaxy]
+			parts = append(parts, fmt.Sprintf("%d(%d,%d) %g", n.galaxy+1, other.x, other.y, n.dist))
+		}
+		fmt.Fprintf(&buff, "%d(%d,%d): %s\n", i+1, g.x, g.y, strings.Join(parts, ", "))
+	}
+
+	if a, b, dist := u.FarthestPair(); a >= 0 {
+		fmt.Fprintf(&buff, "Farthest: %d(%d,%d) - %d(%d,%d) %g\n",
+			a+1, u.galaxies[a].x, u.galaxies[a].y, b+1, u.galaxies[b].x, u.galaxies[b].y, dist)
+	}
+
+	fmt.Fprintln(&buff, "Distances:")
+	buff.WriteString(u.Histogram(bins).String())
+
+	return buff.String()
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
-	"math"
+	"math/big"
 	"os"
+	"slices"
 )
 
 type Grid [][]rune
@@ -44,39 +46,61 @@ func isEmptyCol(grid Grid, col int) bool {
 	return true
 }
 
-func expandGrid(grid Grid) Grid {
-	// Create the new grid - expand horizontally first
-	ngH := make(Grid, len(grid))
-
-	hExpansion := 0
+// Prefix sums of the empty rows and columns. rows[y] is the number of
+// empty rows above row y, cols[x] the number of empty columns left of x
+type Expansion struct {
+	rows, cols []int
+}
 
-	for x := range grid[0] {
-		for y := range grid {
-			ngH[y] = append(ngH[y], grid[y][x])
+func getExpansion(grid Grid) (exp Expansion) {
+	exp.rows = make([]int, len(grid)+1)
+	for y := range grid {
+		exp.rows[y+1] = exp.rows[y]
+		if isEmptyRow(grid[y]) {
+			exp.rows[y+1]++
 		}
+	}
 
+	width := 0
+	if len(grid) > 0 {
+		width = len(grid[0])
+	}
+	exp.cols = make([]int, width+1)
+	for x := 0; x < width; x++ {
+		exp.cols[x+1] = exp.cols[x]
 		if isEmptyCol(grid, x) {
-			hExpansion++
-			for y := range grid {
-				ngH[y] = append(ngH[y], grid[y][x])
-			}
+			exp.cols[x+1]++
 		}
 	}
 
-	// Expand vertically
-	ngV := make(Grid, 0)
-	vExpansion := 0
-	for _, row := range ngH {
-		ngV = append(ngV, row)
-		if isEmptyRow(row) {
-			ngV = append(ngV, row)
-			vExpansion++
-		}
-	}
+	return exp
+}
 
-	fmt.Println("h:", vExpansion, "v:", hExpansion)
+// Position of a galaxy once every empty row and column is replaced by
+// factor rows or columns. Big enough factors overflow an int64
+func (exp Expansion) expand(c Coord, factor int64) (x, y *big.Int) {
+	grow := big.NewInt(factor - 1)
+	x = new(big.Int).Mul(grow, big.NewInt(int64(exp.cols[c.x])))
+	x.Add(x, big.NewInt(int64(c.x)))
+	y = new(big.Int).Mul(grow, big.NewInt(int64(exp.rows[c.y])))
+	y.Add(y, big.NewInt(int64(c.y)))
+	return x, y
+}
 
-	return ngV
+// Sum of |a - b| over all pairs. Once sorted, the i-th value is larger
+// than the i values before it and smaller than the n-i-1 after it
+func sumPairwiseDiffs(values []*big.Int) *big.Int {
+	slices.SortFunc(values, func(a, b *big.Int) int { return a.Cmp(b) })
+
+	acc := new(big.Int)
+	term := new(big.Int)
+	n := int64(len(values))
+	for i, v := range values {
+		term.SetInt64(2*int64(i) - n + 1)
+		term.Mul(term, v)
+		acc.Add(acc, term)
+	}
+	return acc
 }
 
 func findGalaxies(grid Grid) (galaxies []Coord) {
@@ -93,66 +117,39 @@ func findGalaxies(grid Grid) (galaxies []Coord) {
 	return
 }
 
-func solveA(grid Grid) (solnA float64) {
-	grid = expandGrid(grid)
+// Taxi-cab distances split into independent x and y sums, so there's no
+// need to look at every pair of galaxies
+func solve(grid Grid, factor int64) *big.Int {
 	galaxies := findGalaxies(grid)
+	exp := getExpansion(grid)
 
-	fmt.Println("Processing", len(galaxies), "galaxies")
-
-	for _, g1 := range galaxies {
-		for _, g2 := range galaxies {
-      dist := math.Abs(float64(g1.x-g2.x)) + math.Abs(float64(g1.y-g2.y))
-      // fmt.Println(g1, g2, dist)
-			solnA += dist
-		}
+	xs := make([]*big.Int, len(galaxies))
+	ys := make([]*big.Int, len(galaxies))
+	for i, g := range galaxies {
+		xs[i], ys[i] = exp.expand(g, factor)
 	}
 
-	return solnA / 2
+	return new(big.Int).Add(sumPairwiseDiffs(xs), sumPairwiseDiffs(ys))
 }
 
-func getEmptyRowCount(grid Grid, start, end int) (count int) {
-	for y := start; y < end; y++ {
-		if isEmptyRow(grid[y]) {
-			count++
-		}
-	}
-	return
+func solveA(grid Grid) *big.Int {
+	return solve(grid, 2)
 }
 
-func getEmptyColCount(grid Grid, start, end int) (count int) {
-	for x := start; x < end; x++ {
-		if isEmptyCol(grid, x) {
-			count++
-		}
-	}
-	return
+func solveB(grid Grid, factor int64) *big.Int {
+	return solve(grid, factor)
 }
 
-func solveB(grid Grid) (solnB float64) {
-  // B is similar to A, just that the expansion is 1_000_000 instead of 2
-  // Since we're using taxi-cab distance, we can directly add the added distance
-  // no need to actually expand the grid
-	galaxies := findGalaxies(grid)
-
-	fmt.Println("Processing", len(galaxies), "galaxies")
-
-	for _, g1 := range galaxies {
-		for _, g2 := range galaxies {
-      dist := math.Abs(float64(g1.x-g2.x)) + math.Abs(float64(g1.y-g2.y))
-			rowCount := (1_000_000-1) * getEmptyRowCount(grid, min(g1.y, g2.y), max(g1.y, g2.y))
-			colCount := (1_000_000-1) * getEmptyColCount(grid, min(g1.x, g2.x), max(g1.x, g2.x))
-
-			dist += float64(rowCount + colCount)
-      // fmt.Println(g1, g2, dist, fmt.Sprintf("+(%d, %d)", colCount, rowCount))
-      solnB += dist
-		}
-	}
-
-	return solnB / 2
-}
+var inputFile = flag.String("input", "day11/in.txt", "puzzle input")
+var factor = flag.Int64("factor", 1_000_000, "how many rows or columns each empty one expands to in part B")
+var analyse = flag.Bool("analyse", false, "report nearest galaxies and the distance distribution")
+var metricName = flag.String("metric", "manhattan", "distance used by -analyse: manhattan, chebyshev or euclidean")
+var nearest = flag.Int("k", 3, "how many nearest galaxies -analyse lists")
+var bins = flag.Int("bins", 10, "histogram bins for -analyse")
 
 func main() {
-	grid := parseInput("day11/in.txt")
+	flag.Parse()
+	grid := parseInput(*inputFile)
 
 	if len(grid) < 80 {
 		for y := range grid {
@@ -168,8 +165,17 @@ func main() {
   // }
 
 	solnA := solveA(grid)
-	fmt.Println("A:", int(solnA))
+	fmt.Println("A:", solnA)
+
+	solnB := solveB(grid, *factor)
+	fmt.Println("B:", solnB)
 
-	solnB := solveB(grid)
-	fmt.Println("B:", int(solnB))
+	if *analyse {
+		metric, ok := getMetric(*metricName)
+		if !ok {
+			fmt.Println("unknown metric:", *metricName)
+			os.Exit(1)
+		}
+		fmt.Print(getUniverse(grid, *factor, metric).Report(*nearest, max(*bins, 1)))
+	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/big"
+)
+
+// Counts arrangements bottom-up over (spring index, group index). The
+// table is kept between rows so solving many rows doesn't reallocate
+type Counter struct {
+	table  []big.Int
+	groups int
+	// dots[i] is the number of SpringActive in springs[:i]
+	dots []int
+}
+
+func (c *Counter) grow(springs, groups int) {
+	size := (springs + 1) * (groups + 1)
+	if cap(c.table) < size {
+		c.table = make([]big.Int, size)
+	}
+	c.table = c.table[:size]
+	c.groups = groups
+
+	if cap(c.dots) < springs+1 {
+		c.dots = make([]int, springs+1)
+	}
+	c.dots = c.dots[:springs+1]
+}
+
+// Can a group of damaged springs start at i and be followed by a gap?
+// springs always ends in a SpringActive, so there's no edge to worry about
+func (c *Counter) canPlace(springs []Spring, i, size int) bool {
+	if i+size >= len(springs) {
+		return false
+	}
+	return c.dots[i+size] == c.dots[i] && springs[i+size] != SpringDamaged
+}
+
+// Number of arrangements of springs[i:] using ecc[g:], for the last row
+// counted
+func (c *Counter) ways(i, g int) *big.Int {
+	return &c.table[i*(c.groups+1)+g]
+}
+
+// springs must end with a SpringActive
+func (c *Counter) Count(springs []Spring, ecc []int) *big.Int {
+	n, m := len(springs), len(ecc)
+	c.grow(n, m)
+
+	for i, s := range springs {
+		c.dots[i+1] = c.dots[i]
+		if s == SpringActive {
+			c.dots[i+1]++
+		}
+	}
+
+	for g := 0; g < m; g++ {
+		c.ways(n, g).SetInt64(0)
+	}
+	c.ways(n, m).SetInt64(1)
+
+	for i := n - 1; i >= 0; i-- {
+		for g := m; g >= 0; g-- {
+			w := c.ways(i, g)
+			w.SetInt64(0)
+
+			if springs[i] != SpringDamaged {
+				w.Add(w, c.ways(i+1, g))
+			}
+			if g < m && springs[i] != SpringActive && c.canPlace(springs, i, ecc[g]) {
+				w.Add(w, c.ways(i+ecc[g]+1, g+1))
+			}
+		}
+	}
+
+	return new(big.Int).Set(c.ways(0, 0))
+}
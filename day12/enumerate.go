@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math/big"
+	"slices"
+)
+
+type frame struct {
+	i, g   int
+	size   int // 0 to leave spring i active, else the group placed at i
+	bufLen int
+}
+
+// Lazily walks the valid arrangements of a row. Branches that can't lead
+// to an arrangement are pruned using the counting table, so every step
+// of the walk ends in an arrangement
+type Arrangements struct {
+	springs []Spring
+	ecc     []int
+	counter Counter
+	stack   []frame
+	buf     []Spring
+	limit   int
+	yielded int
+}
+
+// A limit of 0 yields every arrangement
+func NewArrangements(row Row, limit int) *Arrangements {
+	it := &Arrangements{
+		springs: append(slices.Clone(row.springs), SpringActive),
+		ecc:     row.ecc,
+		limit:   limit,
+	}
+	it.counter.Count(it.springs, it.ecc)
+	it.pushChoices(0, 0, 0)
+	return it
+}
+
+func (it *Arrangements) pushChoices(i, g, bufLen int) {
+	c := &it.counter
+	if i >= len(it.springs) {
+		return
+	}
+
+	if g < len(it.ecc) && it.springs[i] != SpringActive && c.canPlace(it.springs, i, it.ecc[g]) &&
+		c.ways(i+it.ecc[g]+1, g+1).Sign() > 0 {
+		it.stack = append(it.stack, frame{i, g, it.ecc[g], bufLen})
+	}
+	if it.springs[i] != SpringDamaged && c.ways(i+1, g).Sign() > 0 {
+		it.stack = append(it.stack, frame{i, g, 0, bufLen})
+	}
+}
+
+// Returns the next arrangement, or false once there are none left or the
+// limit is reached
+func (it *Arrangements) Next() ([]Spring, bool) {
+	for len(it.stack) > 0 && (it.limit == 0 || it.yielded < it.limit) {
+		f := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+		it.buf = it.buf[:f.bufLen]
+
+		next, g := f.i+1, f.g
+		if f.size > 0 {
+			for j := 0; j < f.size; j++ {
+				it.buf = append(it.buf, SpringDamaged)
+			}
+			next, g = f.i+f.size+1, f.g+1
+		}
+		it.buf = append(it.buf, SpringActive)
+
+		if next == len(it.springs) && g == len(it.ecc) {
+			it.yielded++
+			// Drop the trailing `.` added for boundary checking
+			return slices.Clone(it.buf[:len(it.buf)-1]), true
+		}
+		it.pushChoices(next, g, len(it.buf))
+	}
+
+	return nil, false
+}
+
+// Unknown springs that are damaged in every arrangement, and those that
+// are active in every arrangement. Works by counting how many
+// arrangements pass through every step of the counting table, so
+// nothing is enumerated
+func deduce(row Row) (damaged, active []int) {
+	springs := append(slices.Clone(row.springs), SpringActive)
+	ecc := row.ecc
+	n, m := len(springs), len(ecc)
+
+	var c Counter
+	total := c.Count(springs, ecc)
+	if total.Sign() == 0 {
+		return nil, nil
+	}
+
+	// reach[i][g] is the number of ways to get to spring i having
+	// placed ecc[:g]
+	reach := make([]big.Int, (n+1)*(m+1))
+	at := func(i, g int) *big.Int { return &reach[i*(m+1)+g] }
+	at(0, 0).SetInt64(1)
+
+	// Arrangements where spring i is damaged, as a difference array
+	// over the start and end of every group
+	diff := make([]big.Int, n+1)
+	through := new(big.Int)
+
+	for i := 0; i < n; i++ {
+		for g := 0; g <= m; g++ {
+			r := at(i, g)
+			if r.Sign() == 0 {
+				continue
+			}
+
+			if springs[i] != SpringDamaged {
+				at(i+1, g).Add(at(i+1, g), r)
+			}
+			if g < m && springs[i] != SpringActive && c.canPlace(springs, i, ecc[g]) {
+				size := ecc[g]
+				at(i+size+1, g+1).Add(at(i+size+1, g+1), r)
+
+				through.Mul(r, c.ways(i+size+1, g+1))
+				diff[i].Add(&diff[i], through)
+				diff[i+size].Sub(&diff[i+size], through)
+			}
+		}
+	}
+
+	count := new(big.Int)
+	for i, s := range row.springs {
+		count.Add(count, &diff[i])
+		if s != SpringUnknown {
+			continue
+		}
+		if count.Cmp(total) == 0 {
+			damaged = append(damaged, i)
+		} else if count.Sign() == 0 {
+			active = append(active, i)
+		}
+	}
+
+	return damaged, active
+}
@@ -2,8 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
+	"os/signal"
+	"runtime"
 	"strconv"
 	"time"
 )
@@ -290,30 +295,75 @@ func countValidPos(row []Spring, ecc []int, cache map[string]int) (count, cacheH
 	return
 }
 
-func solveB(in Input) (solnB int) {
+func solveB(ctx context.Context, in Input, factor, workers int) (*big.Int, error) {
 	defer timer("solveB")()
-	unfoldedIn := unfoldInput(in, 5)
 
-	cache := make(map[string]int)
-	cacheHit, processed := 0, 0
+	results, err := countRowsParallel(ctx, unfoldInput(in, factor), workers)
+	if err != nil {
+		return nil, err
+	}
+
+	solnB := new(big.Int)
+	for _, res := range results {
+		if *verbose {
+			fmt.Printf("row %d: %s in %v\n", res.row+1, res.count, res.took)
+		}
+		solnB.Add(solnB, res.count)
+	}
+	return solnB, nil
+}
+
+func countUnfolded(in Input, factor int) *big.Int {
+	unfoldedIn := unfoldInput(in, factor)
+
+	var counter Counter
+	total := new(big.Int)
 
 	for _, row := range unfoldedIn {
 		// Add a `.` to make boundary checking easier
 		row.springs = append(row.springs, SpringActive)
+		total.Add(total, counter.Count(row.springs, row.ecc))
+	}
 
-		count, nCacheHit, nProcessed := countValidPos(row.springs, row.ecc, cache)
-		cacheHit += nCacheHit
-		processed += nProcessed
+	return total
+}
+
+// What solveB used to do before the index based table
+func countUnfoldedMemo(in Input, factor int) (total int) {
+	unfoldedIn := unfoldInput(in, factor)
+	cache := make(map[string]int)
+
+	for _, row := range unfoldedIn {
+		row.springs = append(row.springs, SpringActive)
+		count, _, _ := countValidPos(row.springs, row.ecc, cache)
+		total += count
+	}
+
+	return total
+}
 
-		hash := string(row.springs) + fmt.Sprint(row.ecc)
-		fmt.Println(hash+":", count)
+// Times both part B implementations on the same input
+func benchmark(in Input, factor, rounds int) {
+	var memo int
+	var table *big.Int
 
-		solnB += count
+	start := time.Now()
+	for i := 0; i < rounds; i++ {
+		memo = countUnfoldedMemo(in, factor)
 	}
+	memoTime := time.Since(start) / time.Duration(rounds)
 
-	fmt.Println("Processed:", processed, " -- Cache Hit:", cacheHit)
+	start = time.Now()
+	for i := 0; i < rounds; i++ {
+		table = countUnfolded(in, factor)
+	}
+	tableTime := time.Since(start) / time.Duration(rounds)
 
-	return solnB
+	fmt.Printf("memo:  %v per round, %d\n", memoTime, memo)
+	fmt.Printf("table: %v per round, %s\n", tableTime, table)
+	if !table.IsInt64() || table.Int64() != int64(memo) {
+		fmt.Println("MISMATCH")
+	}
 }
 
 func timer(name string) func() {
@@ -323,8 +373,42 @@ func timer(name string) func() {
 	}
 }
 
+var inputFile = flag.String("input", "day12/in.txt", "puzzle input")
+var factor = flag.Int("factor", 5, "how many times part B unfolds each row")
+var enumerate = flag.Int("enumerate", 0, "print up to this many arrangements of every row, 0 to skip")
+var deduceRows = flag.Bool("deduce", false, "print the unknown springs every arrangement of the unfolded rows agrees on")
+var workers = flag.Int("workers", runtime.NumCPU(), "rows counted in parallel in part B")
+var timeout = flag.Duration("timeout", 0, "give up on part B after this long, 0 for no limit")
+var verbose = flag.Bool("v", false, "print the count and time taken for every row in part B")
+var bench = flag.Int("bench", 0, "rounds to benchmark the table against the memoized recursion, 0 to skip")
+
 func main() {
-	in := parseInput("day12/in.txt")
+	flag.Parse()
+	in := parseInput(*inputFile)
+
+	if *enumerate > 0 {
+		for _, row := range in {
+			fmt.Printf("%s %v\n", string(row.springs), row.ecc)
+			it := NewArrangements(row, *enumerate)
+			for pos, ok := it.Next(); ok; pos, ok = it.Next() {
+				fmt.Println(" ", string(pos))
+			}
+		}
+		return
+	}
+
+	if *deduceRows {
+		for _, row := range unfoldInput(in, *factor) {
+			damaged, active := deduce(row)
+			fmt.Printf("%s %v\n  damaged: %v\n  active: %v\n", string(row.springs), row.ecc, damaged, active)
+		}
+		return
+	}
+
+	if *bench > 0 {
+		benchmark(in, *factor, *bench)
+		return
+	}
 
 	// if len(in[0].springs) < 80 {
 	//   for y := range in {
@@ -344,7 +428,19 @@ func main() {
 	// solnA := solveA(in)
 	// fmt.Println("A:", solnA)
 
-	// This takes too long!!
-	solnB := solveB(in)
+	// Ctrl-C cancels part B instead of killing it mid-row
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	solnB, err := solveB(ctx, in, *factor, *workers)
+	if err != nil {
+		fmt.Println("B:", err)
+		os.Exit(1)
+	}
 	fmt.Println("B:", solnB)
 }
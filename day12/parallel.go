@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+)
+
+type RowResult struct {
+	row   int
+	count *big.Int
+	took  time.Duration
+}
+
+// Counts every row on a pool of workers. Each worker has its own Counter,
+// so nothing is shared between them. Results are in the same order as
+// the rows. Stops early with the context's error once it's cancelled
+func countRowsParallel(ctx context.Context, rows Input, workers int) ([]RowResult, error) {
+	results := make([]RowResult, len(rows))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var counter Counter
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				start := time.Now()
+				// Add a `.` to make boundary checking easier
+				springs := append(rows[i].springs[:len(rows[i].springs):len(rows[i].springs)], SpringActive)
+				count := counter.Count(springs, rows[i].ecc)
+				results[i] = RowResult{i, count, time.Since(start)}
+			}
+		}()
+	}
+
+feed:
+	for i := range rows {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import "math/bits"
+
+// A line of a pattern, one bit per cell, set for `#`. Lines longer than
+// 64 cells carry on into the next word
+type Bits []uint64
+
+func newBits(n int) Bits {
+	return make(Bits, (n+63)/64)
+}
+
+func (b Bits) set(i int) {
+	b[i/64] |= 1 << (i % 64)
+}
+
+// Number of cells that differ between two lines of the same length
+func diffCount(a, b Bits) (count int) {
+	for i := range a {
+		count += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return count
+}
+
+type BitPattern struct {
+	rows []Bits
+	cols []Bits
+}
+
+func encodePattern(pattern Pattern) BitPattern {
+	bp := BitPattern{
+		rows: make([]Bits, len(pattern)),
+		cols: make([]Bits, len(pattern[0])),
+	}
+	for x := range bp.cols {
+		bp.cols[x] = newBits(len(pattern))
+	}
+
+	for y, row := range pattern {
+		bp.rows[y] = newBits(len(row))
+		for x, cell := range row {
+			if cell == '#' {
+				bp.rows[y].set(x)
+				bp.cols[x].set(y)
+			}
+		}
+	}
+
+	return bp
+}
+
+// Smudges across a mirror between lines[pos-1] and lines[pos]. Gives up
+// as soon as there are more than smudgeLimit
+func mirrorSmudges(lines []Bits, pos, smudgeLimit int) (smudgeCount int, ok bool) {
+	for i := 0; pos-i-1 >= 0 && pos+i < len(lines); i++ {
+		smudgeCount += diffCount(lines[pos-i-1], lines[pos+i])
+		if smudgeCount > smudgeLimit {
+			return smudgeCount, false
+		}
+	}
+	return smudgeCount, true
+}
+
+// Mirror positions with exactly smudgeLimit smudges. Mirroring the
+// columns gives the vertical mirrors, the rows the horizontal ones
+func findMirrors(lines []Bits, smudgeLimit int) (positions []int) {
+	for pos := 1; pos < len(lines); pos++ {
+		if count, ok := mirrorSmudges(lines, pos, smudgeLimit); ok && count == smudgeLimit {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
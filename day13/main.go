@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"os"
 )
@@ -95,48 +96,58 @@ func transpose(pattern Pattern) Pattern {
 	return transposed
 }
 
-func getScore(input Input, smudgeLimit int) (score int) {
+// Mirror positions along the lines of a pattern with exactly smudgeLimit
+// smudges. For rows these are vertical mirrors, for the transposed
+// pattern horizontal ones
+func findMirrorLines(lines [][]byte, smudgeLimit int) (matches []CandidatePos) {
+	var candidatePos []CandidatePos
+	for _, line := range lines {
+		candidatePos = getMirrorRowPositions(line, candidatePos, smudgeLimit)
+		// fmt.Println(string(line), candidatePos)
+		if len(candidatePos) == 0 {
+			break
+		}
+	}
+	for _, pos := range candidatePos {
+		if pos.smudgeCount == smudgeLimit {
+			matches = append(matches, pos)
+		}
+	}
+	return
+}
+
+// Compares the patterns cell by cell, see getScore
+func getScoreBytes(input Input, smudgeLimit int) (score int) {
 	xMatches := 0
 	yMatches := 0
 
 	for _, pattern := range input {
 		// Check for X-axis symmetry
-		var xCandidatePos []CandidatePos
-		for _, line := range pattern {
-			xCandidatePos = getMirrorRowPositions(line, xCandidatePos, smudgeLimit)
-			// fmt.Println(string(line), xCandidatePos)
-			if len(xCandidatePos) == 0 {
-				break
-			}
+		for _, pos := range findMirrorLines(pattern, smudgeLimit) {
+			xMatches += pos.pos
 		}
-		for _, pos := range xCandidatePos {
-			if pos.smudgeCount == smudgeLimit {
-				xMatches += pos.pos
-			}
+
+		// Check for Y-axis symmetry
+		for _, pos := range findMirrorLines(transpose(pattern), smudgeLimit) {
+			yMatches += pos.pos
 		}
+	}
 
-		// fmt.Println("")
+	return xMatches + 100*yMatches
+}
 
-		// Check for Y-axis symmetry
-		var yCandidatePos []CandidatePos
-		for _, line := range transpose(pattern) {
-			yCandidatePos = getMirrorRowPositions(line, yCandidatePos, smudgeLimit)
-			// fmt.Println(string(line), yCandidatePos)
-			if len(yCandidatePos) == 0 {
-				break
-			}
+func getScore(input Input, smudgeLimit int) (score int) {
+	for _, pattern := range input {
+		bp := encodePattern(pattern)
+		for _, pos := range findMirrors(bp.cols, smudgeLimit) {
+			score += pos
 		}
-		for _, pos := range yCandidatePos {
-			if pos.smudgeCount == smudgeLimit {
-				yMatches += pos.pos
-			}
+		for _, pos := range findMirrors(bp.rows, smudgeLimit) {
+			score += 100 * pos
 		}
-
-		// totalMatches := len(xCandidatePos) + len(yCandidatePos)
-		// fmt.Printf("Summary:\t%d\t%d\t%d\n", totalMatches, xCandidatePos, yCandidatePos)
 	}
 
-	return xMatches + 100*yMatches
+	return score
 }
 
 func solveA(input Input) (solnA int) {
@@ -147,12 +158,46 @@ func solveB(input Input) (solnB int) {
 	return getScore(input, 1)
 }
 
+var inputFile = flag.String("input", "day13/in.txt", "puzzle input")
+var symmetry = flag.Bool("symmetry", false, "look for rotational, diagonal and partial mirror symmetry")
+var smudges = flag.Int("smudges", 0, "smudges allowed by -symmetry")
+var check = flag.Bool("check", false, "compare the bitmask search with the cell by cell one")
+var report = flag.Bool("report", false, "print where every pattern reflects and which cells are smudged")
+var render = flag.Bool("render", false, "draw every pattern with its mirror line and smudges, implies -report")
+
 func main() {
-	input := parseInput("day13/in.txt")
+	flag.Parse()
+	input := parseInput(*inputFile)
 
 	solnA := solveA(input)
 	fmt.Println("A:", solnA)
 
 	solnB := solveB(input)
 	fmt.Println("B:", solnB)
+
+	if *check {
+		for _, smudgeLimit := range []int{0, 1} {
+			bits, cells := getScore(input, smudgeLimit), getScoreBytes(input, smudgeLimit)
+			if bits != cells {
+				fmt.Printf("MISMATCH with %d smudges: bitmask %d, cells %d\n", smudgeLimit, bits, cells)
+			}
+		}
+	}
+
+	if *report || *render {
+		for _, smudgeLimit := range []int{0, 1} {
+			for _, r := range getReflections(input, smudgeLimit) {
+				fmt.Println(r)
+				if *render {
+					fmt.Println(r.Render(input[r.pattern]))
+				}
+			}
+		}
+	}
+
+	if *symmetry {
+		for _, s := range getSymmetries(input, *smudges) {
+			fmt.Print(s)
+		}
+	}
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	AxisColumn = iota // A vertical mirror, pos columns to its left
+	AxisRow    = iota // A horizontal mirror, pos rows above it
+)
+
+// A cell that doesn't match its mirror image
+type Smudge struct {
+	row, col             int
+	mirrorRow, mirrorCol int
+}
+
+type Reflection struct {
+	pattern int
+	axis    int
+	pos     int
+	smudges []Smudge
+}
+
+func (r Reflection) String() string {
+	axis := "column"
+	if r.axis == AxisRow {
+		axis = "row"
+	}
+
+	smudges := make([]string, len(r.smudges))
+	for i, s := range r.smudges {
+		smudges[i] = fmt.Sprintf("(%d, %d)~(%d, %d)", s.row, s.col, s.mirrorRow, s.mirrorCol)
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("Pattern %d: %s %d, %d smudges %s",
+		r.pattern+1, axis, r.pos, len(r.smudges), strings.Join(smudges, " ")))
+}
+
+func getSmudges(pattern Pattern, axis, pos int) (smudges []Smudge) {
+	for y := range pattern {
+		for x := range pattern[y] {
+			// Visit every pair once, from the cell before the mirror
+			if axis == AxisColumn && x < pos {
+				mx := 2*pos - x - 1
+				if mx < len(pattern[y]) && pattern[y][x] != pattern[y][mx] {
+					smudges = append(smudges, Smudge{y, x, y, mx})
+				}
+			}
+			if axis == AxisRow && y < pos {
+				my := 2*pos - y - 1
+				if my < len(pattern) && pattern[y][x] != pattern[my][x] {
+					smudges = append(smudges, Smudge{y, x, my, x})
+				}
+			}
+		}
+	}
+	return smudges
+}
+
+// Every mirror line with exactly smudgeLimit smudges, the same ones
+// getScore adds up
+func getReflections(input Input, smudgeLimit int) (reflections []Reflection) {
+	for i, pattern := range input {
+		bp := encodePattern(pattern)
+		for _, pos := range findMirrors(bp.cols, smudgeLimit) {
+			reflections = append(reflections, Reflection{i, AxisColumn, pos, getSmudges(pattern, AxisColumn, pos)})
+		}
+		for _, pos := range findMirrors(bp.rows, smudgeLimit) {
+			reflections = append(reflections, Reflection{i, AxisRow, pos, getSmudges(pattern, AxisRow, pos)})
+		}
+	}
+	return reflections
+}
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[1;31m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+// The pattern with the mirror line drawn in and the smudged cells in red,
+// next to their mirror images in yellow
+func (r Reflection) Render(pattern Pattern) string {
+	colours := map[[2]int]string{}
+	for _, s := range r.smudges {
+		colours[[2]int{s.row, s.col}] = ansiRed
+		colours[[2]int{s.mirrorRow, s.mirrorCol}] = ansiYellow
+	}
+
+	var buff strings.Builder
+	for y, row := range pattern {
+		if r.axis == AxisRow && y == r.pos {
+			buff.WriteString(ansiCyan + strings.Repeat("─", len(row)) + ansiReset + "\n")
+		}
+		for x, cell := range row {
+			if r.axis == AxisColumn && x == r.pos {
+				buff.WriteString(ansiCyan + "│" + ansiReset)
+			}
+			if colour, ok := colours[[2]int{y, x}]; ok {
+				buff.WriteString(colour + string(cell) + ansiReset)
+			} else {
+				buff.WriteByte(cell)
+			}
+		}
+		buff.WriteString("\n")
+	}
+	return buff.String()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Smudges when turning the pattern by 180°, or false once there are
+// more than smudgeLimit. Every pair of cells is only counted once
+func rotationalSmudges(pattern Pattern, smudgeLimit int) (smudgeCount int, ok bool) {
+	h, w := len(pattern), len(pattern[0])
+	for y := range pattern {
+		for x := range pattern[y] {
+			my, mx := h-1-y, w-1-x
+			if y*w+x >= my*w+mx {
+				return smudgeCount, true
+			}
+			if pattern[y][x] != pattern[my][mx] {
+				smudgeCount++
+				if smudgeCount > smudgeLimit {
+					return smudgeCount, false
+				}
+			}
+		}
+	}
+	return smudgeCount, true
+}
+
+type Square struct {
+	row, col, size int
+	smudges        int
+}
+
+// Smudges across the main diagonal of a square sub-region
+func diagonalSmudges(pattern Pattern, sq Square, smudgeLimit int) (smudgeCount int, ok bool) {
+	for i := 0; i < sq.size; i++ {
+		for j := i + 1; j < sq.size; j++ {
+			if pattern[sq.row+i][sq.col+j] != pattern[sq.row+j][sq.col+i] {
+				smudgeCount++
+				if smudgeCount > smudgeLimit {
+					return smudgeCount, false
+				}
+			}
+		}
+	}
+	return smudgeCount, true
+}
+
+// Largest square sub-region that is its own transpose. Ties go to the
+// square closest to the top left
+func largestDiagonalSquare(pattern Pattern, smudgeLimit int) (best Square, found bool) {
+	h, w := len(pattern), len(pattern[0])
+	for size := min(h, w); size >= 2; size-- {
+		for row := 0; row+size <= h; row++ {
+			for col := 0; col+size <= w; col++ {
+				sq := Square{row, col, size, 0}
+				if count, ok := diagonalSmudges(pattern, sq, smudgeLimit); ok {
+					sq.smudges = count
+					return sq, true
+				}
+			}
+		}
+	}
+	return best, false
+}
+
+type Rect struct {
+	row, col      int
+	height, width int
+	axis          int
+	smudges       int
+}
+
+func (r Rect) area() int { return r.height * r.width }
+
+// Largest sub-rectangle mirrored about its own centre line. For every
+// mirror and half width, smudges[y] holds the smudges on row y, so the
+// best run of rows is found with two pointers
+func largestMirroredRect(h, w int, cell func(y, x int) byte, smudgeLimit int) (best Rect) {
+	smudges := make([]int, h)
+
+	for mirror := 1; mirror < w; mirror++ {
+		clear(smudges)
+
+		for half := 1; mirror-half >= 0 && mirror+half-1 < w; half++ {
+			for y := 0; y < h; y++ {
+				if cell(y, mirror-half) != cell(y, mirror+half-1) {
+					smudges[y]++
+				}
+			}
+
+			top, total := 0, 0
+			for y := 0; y < h; y++ {
+				total += smudges[y]
+				for total > smudgeLimit {
+					total -= smudges[top]
+					top++
+				}
+				rect := Rect{top, mirror - half, y - top + 1, 2 * half, AxisColumn, total}
+				if rect.height > 0 && rect.area() > best.area() {
+					best = rect
+				}
+			}
+		}
+	}
+
+	return best
+}
+
+func largestSymmetricRect(pattern Pattern, smudgeLimit int) Rect {
+	h, w := len(pattern), len(pattern[0])
+
+	byColumn := largestMirroredRect(h, w, func(y, x int) byte { return pattern[y][x] }, smudgeLimit)
+
+	// Same search with rows and columns swapped, then swapped back
+	byRow := largestMirroredRect(w, h, func(y, x int) byte { return pattern[x][y] }, smudgeLimit)
+	byRow = Rect{byRow.col, byRow.row, byRow.width, byRow.height, AxisRow, byRow.smudges}
+
+	if byRow.area() > byColumn.area() {
+		return byRow
+	}
+	return byColumn
+}
+
+type Symmetries struct {
+	pattern    int
+	rotational bool
+	rotSmudges int
+	diagonal   Square
+	hasSquare  bool
+	rect       Rect
+}
+
+func getSymmetries(input Input, smudgeLimit int) []Symmetries {
+	all := make([]Symmetries, len(input))
+	for i, pattern := range input {
+		s := Symmetries{pattern: i}
+		s.rotSmudges, s.rotational = rotationalSmudges(pattern, smudgeLimit)
+		s.diagonal, s.hasSquare = largestDiagonalSquare(pattern, smudgeLimit)
+		s.rect = largestSymmetricRect(pattern, smudgeLimit)
+		all[i] = s
+	}
+	return all
+}
+
+func (s Symmetries) String() string {
+	var buff strings.Builder
+	fmt.Fprintf(&buff, "Pattern %d:\n", s.pattern+1)
+
+	if s.rotational {
+		fmt.Fprintf(&buff, "  rotational: yes, %d smudges\n", s.rotSmudges)
+	} else {
+		fmt.Fprintln(&buff, "  rotational: no")
+	}
+
+	if s.hasSquare {
+		d := s.diagonal
+		fmt.Fprintf(&buff, "  diagonal: %dx%d square at (%d, %d), %d smudges\n", d.size, d.size, d.row, d.col, d.smudges)
+	} else {
+		fmt.Fprintln(&buff, "  diagonal: none")
+	}
+
+	axis := "column"
+	if s.rect.axis == AxisRow {
+		axis = "row"
+	}
+	r := s.rect
+	fmt.Fprintf(&buff, "  mirrored: %dx%d at (%d, %d) about its centre %s, %d smudges\n",
+		r.height, r.width, r.row, r.col, axis, r.smudges)
+
+	return buff.String()
+}
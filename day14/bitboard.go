@@ -0,0 +1,243 @@
+package main
+
+import "math/bits"
+
+// One bit per cell of a row, bit x for column x. Rows wider than 64
+// cells carry on into the next word
+type Bits []uint64
+
+func newBits(n int) Bits {
+	return make(Bits, (n+63)/64)
+}
+
+func (b Bits) set(i int)      { b[i/64] |= 1 << (i % 64) }
+func (b Bits) has(i int) bool { return b[i/64]&(1<<(i%64)) != 0 }
+
+func (b Bits) isZero() bool {
+	for _, w := range b {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b Bits) count() (n int) {
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Moves every bit one column left, towards x = 0
+func (b Bits) shiftDown(out Bits) {
+	for i := range b {
+		out[i] = b[i] >> 1
+		if i+1 < len(b) {
+			out[i] |= b[i+1] << 63
+		}
+	}
+}
+
+// Moves every bit one column right, the caller masks off the overflow
+func (b Bits) shiftUp(out Bits) {
+	for i := len(b) - 1; i >= 0; i-- {
+		out[i] = b[i] << 1
+		if i > 0 {
+			out[i] |= b[i-1] >> 63
+		}
+	}
+}
+
+// Transposes a 64x64 block of bits in place, bit j of a[i] ends up as
+// bit i of a[j]. Swaps ever smaller off-diagonal blocks, 32x32 first
+func transpose64(a *[64]uint64) {
+	m := uint64(0x00000000FFFFFFFF)
+	for j := 32; j != 0; j, m = j>>1, m^(m<<(j>>1)) {
+		for k := 0; k < 64; k = (k + j + 1) &^ j {
+			t := ((a[k] >> j) ^ a[k+j]) & m
+			a[k+j] ^= t
+			a[k] ^= t << j
+		}
+	}
+}
+
+// Turns rows into columns or the other way round, a 64x64 block at a
+// time
+func transposeBits(src, dst []Bits) {
+	var block [64]uint64
+	for by := 0; by*64 < len(src); by++ {
+		for bx := 0; bx*64 < len(dst); bx++ {
+			for i := range block {
+				block[i] = 0
+				if by*64+i < len(src) {
+					block[i] = src[by*64+i][bx]
+				}
+			}
+			transpose64(&block)
+			for j := 0; j < 64 && bx*64+j < len(dst); j++ {
+				dst[bx*64+j][by] = block[j]
+			}
+		}
+	}
+}
+
+// Round and square rocks of every row, or of every column
+type bitLines struct {
+	round, square []Bits
+	// Bits inside the platform, to drop whatever is shifted past the edge
+	mask Bits
+	// Scratch space for tilting
+	free, moved, tmp Bits
+}
+
+func newBitLines(lines, length int) bitLines {
+	bl := bitLines{
+		round:  make([]Bits, lines),
+		square: make([]Bits, lines),
+		mask:   newBits(length),
+		free:   newBits(length),
+		moved:  newBits(length),
+		tmp:    newBits(length),
+	}
+	for i := range bl.round {
+		bl.round[i], bl.square[i] = newBits(length), newBits(length)
+	}
+	for i := 0; i < length; i++ {
+		bl.mask.set(i)
+	}
+	return bl
+}
+
+// Shifts every rock one cell towards the edge wherever that's free,
+// until nothing moves. toLow rolls towards bit 0
+func (bl *bitLines) tiltLine(line, square Bits, toLow bool) {
+	for {
+		for i := range bl.free {
+			bl.free[i] = bl.mask[i] &^ (line[i] | square[i])
+		}
+
+		if toLow {
+			line.shiftDown(bl.moved)
+		} else {
+			line.shiftUp(bl.moved)
+		}
+		for i := range bl.moved {
+			bl.moved[i] &= bl.free[i]
+		}
+		if bl.moved.isZero() {
+			return
+		}
+
+		// Clear where the moved rocks came from
+		if toLow {
+			bl.moved.shiftUp(bl.tmp)
+		} else {
+			bl.moved.shiftDown(bl.tmp)
+		}
+		for i := range line {
+			line[i] = (line[i] &^ bl.tmp[i]) | bl.moved[i]
+		}
+	}
+}
+
+func (bl *bitLines) tilt(toLow bool) {
+	for i := range bl.round {
+		bl.tiltLine(bl.round[i], bl.square[i], toLow)
+	}
+}
+
+// Rocks as bitsets per row and per column. Tilting west or east shifts
+// the row bitsets, tilting north or south the column ones, so every tilt
+// is done with word-level shifts and masks. Only one of the two has the
+// current round rocks, the other is brought up to date with a blocked
+// transpose when the tilt axis changes
+type BitPlatform struct {
+	width, height int
+	rows, cols    bitLines
+	rowsCurrent   bool
+}
+
+func newBitPlatform(platform Platform) *BitPlatform {
+	width, height := len(platform[0]), len(platform)
+	bp := &BitPlatform{
+		width:       width,
+		height:      height,
+		rows:        newBitLines(height, width),
+		cols:        newBitLines(width, height),
+		rowsCurrent: true,
+	}
+
+	for y, row := range platform {
+		for x, cell := range row {
+			switch Rock(cell) {
+			case RRound:
+				bp.rows.round[y].set(x)
+			case RSquare:
+				bp.rows.square[y].set(x)
+				bp.cols.square[x].set(y)
+			}
+		}
+	}
+
+	return bp
+}
+
+func (bp *BitPlatform) syncRows() {
+	if !bp.rowsCurrent {
+		transposeBits(bp.cols.round, bp.rows.round)
+		bp.rowsCurrent = true
+	}
+}
+
+func (bp *BitPlatform) syncCols() {
+	if bp.rowsCurrent {
+		transposeBits(bp.rows.round, bp.cols.round)
+		bp.rowsCurrent = false
+	}
+}
+
+func (bp *BitPlatform) Platform() Platform {
+	bp.syncRows()
+
+	platform := make(Platform, bp.height)
+	for y := range platform {
+		platform[y] = make([]byte, bp.width)
+		for x := range platform[y] {
+			switch {
+			case bp.rows.round[y].has(x):
+				platform[y][x] = byte(RRound)
+			case bp.rows.square[y].has(x):
+				platform[y][x] = byte(RSquare)
+			default:
+				platform[y][x] = byte(RTile)
+			}
+		}
+	}
+	return platform
+}
+
+func (bp *BitPlatform) countScore() (score int) {
+	bp.syncRows()
+	for y, row := range bp.rows.round {
+		score += row.count() * (bp.height - y)
+	}
+	return score
+}
+
+func (bp *BitPlatform) tilt(dir Dir) {
+	switch dir {
+	case North, South:
+		bp.syncCols()
+		bp.cols.tilt(dir == North)
+	case West, East:
+		bp.syncRows()
+		bp.rows.tilt(dir == West)
+	}
+}
+
+func (bp *BitPlatform) runSequence(seq []Dir) {
+	for _, dir := range seq {
+		bp.tilt(dir)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+)
+
+// A platform along with a Zobrist hash of where its round rocks are.
+// Every cell has a random key, the hash is the XOR of the keys under the
+// round rocks, so moving a rock only takes two XORs
+type SpinState struct {
+	platform Platform
+	keys     []uint64
+	hash     uint64
+}
+
+func newSpinState(platform Platform) *SpinState {
+	width := len(platform[0])
+	// Fixed seed, so hashes are the same from one run to the next
+	rng := rand.New(rand.NewSource(14))
+
+	s := &SpinState{platform: platform, keys: make([]uint64, len(platform)*width)}
+	for i := range s.keys {
+		s.keys[i] = rng.Uint64()
+	}
+	for y := range platform {
+		for x := range platform[y] {
+			if Rock(platform[y][x]) == RRound {
+				s.hash ^= s.keys[y*width+x]
+			}
+		}
+	}
+	return s
+}
+
+func (p Platform) Clone() Platform {
+	platform := make(Platform, len(p))
+	for y := range p {
+		platform[y] = bytes.Clone(p[y])
+	}
+	return platform
+}
+
+func (s *SpinState) clone() *SpinState {
+	return &SpinState{s.platform.Clone(), s.keys, s.hash}
+}
+
+func (s *SpinState) spin(cycle []Dir) {
+	width := len(s.platform[0])
+	for _, dir := range cycle {
+		sweep(s.platform, dir, func(x, y, toX, toY int) {
+			s.hash ^= s.keys[y*width+x] ^ s.keys[toY*width+toX]
+		})
+	}
+}
+
+type CycleInfo struct {
+	start, length int
+	// Times two different platforms had the same hash
+	collisions int
+}
+
+// Hashes decide quickly, the platforms themselves make sure
+func (info *CycleInfo) same(a, b *SpinState) bool {
+	if a.hash != b.hash {
+		return false
+	}
+	for y := range a.platform {
+		if !bytes.Equal(a.platform[y], b.platform[y]) {
+			info.collisions++
+			return false
+		}
+	}
+	return true
+}
+
+// Brent's algorithm. Only ever keeps two platforms around, instead of
+// every one seen so far
+func findCycle(initial *SpinState, cycle []Dir) (info CycleInfo) {
+	power, length := 1, 1
+	tortoise := initial.clone()
+	hare := initial.clone()
+	hare.spin(cycle)
+
+	for !info.same(tortoise, hare) {
+		if power == length {
+			tortoise = hare.clone()
+			power *= 2
+			length = 0
+		}
+		hare.spin(cycle)
+		length++
+	}
+	info.length = length
+
+	// Find where the cycle starts by walking two platforms, length apart
+	tortoise = initial.clone()
+	hare = initial.clone()
+	for i := 0; i < length; i++ {
+		hare.spin(cycle)
+	}
+	for !info.same(tortoise, hare) {
+		tortoise.spin(cycle)
+		hare.spin(cycle)
+		info.start++
+	}
+
+	return info
+}
+
+// The platform and its north load after n spin cycles. Anything past the
+// start of the cycle is skipped, so n can be as large as needed
+func afterCycles(platform Platform, cycle []Dir, n int) (Platform, int, CycleInfo) {
+	initial := newSpinState(platform)
+	info := findCycle(initial, cycle)
+
+	steps := n
+	if n > info.start {
+		steps = info.start + (n-info.start)%info.length
+	}
+
+	state := initial.clone()
+	for i := 0; i < steps; i++ {
+		state.spin(cycle)
+	}
+
+	return state.platform, countScore(state.platform), info
+}
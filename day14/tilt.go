@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Dir byte
+
+const (
+	North Dir = 'N'
+	West  Dir = 'W'
+	South Dir = 'S'
+	East  Dir = 'E'
+)
+
+// Position of the i-th cell of a line, counting from the edge the rocks
+// roll towards. Lines are columns when tilting north or south, rows
+// otherwise
+func (p Platform) cellAt(dir Dir, line, i int) (x, y int) {
+	switch dir {
+	case North:
+		return line, i
+	case South:
+		return line, len(p) - 1 - i
+	case West:
+		return i, line
+	default:
+		return len(p[0]) - 1 - i, line
+	}
+}
+
+// Rolls every round rock as far as it goes. Each line is swept once from
+// the edge, keeping track of the next free slot a rock can land in
+func tilt(platform Platform, dir Dir) Platform {
+	return sweep(platform, dir, nil)
+}
+
+// Same as tilt, calling moved (if not nil) for every rock that moves
+func sweep(platform Platform, dir Dir, moved func(x, y, toX, toY int)) Platform {
+	lines, length := len(platform[0]), len(platform)
+	if dir == West || dir == East {
+		lines, length = length, lines
+	}
+
+	for line := 0; line < lines; line++ {
+		free := 0
+		for i := 0; i < length; i++ {
+			x, y := platform.cellAt(dir, line, i)
+
+			switch Rock(platform[y][x]) {
+			case RSquare:
+				free = i + 1
+			case RRound:
+				fx, fy := platform.cellAt(dir, line, free)
+				free++
+				if fx == x && fy == y {
+					continue
+				}
+				platform[y][x] = byte(RTile)
+				platform[fy][fx] = byte(RRound)
+				if moved != nil {
+					moved(x, y, fx, fy)
+				}
+			}
+		}
+	}
+
+	return platform
+}
+
+// Reads tilts like "NWSE" or "N,N,E"
+func parseSequence(seq string) (dirs []Dir, err error) {
+	for _, r := range strings.ToUpper(seq) {
+		// Check the rune itself, converting first would cut it to a byte
+		switch r {
+		case rune(North), rune(West), rune(South), rune(East):
+			dirs = append(dirs, Dir(r))
+		case ',', ' ':
+			continue
+		default:
+			return nil, fmt.Errorf("unknown direction %q in %q", r, seq)
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("empty tilt sequence")
+	}
+	return dirs, nil
+}
+
+func runSequence(platform Platform, seq []Dir) Platform {
+	for _, dir := range seq {
+		platform = tilt(platform, dir)
+	}
+	return platform
+}
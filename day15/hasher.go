@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Hasher struct {
+	multiplier int
+	modulus    int
+	initial    int
+}
+
+var DefaultHasher = Hasher{17, 256, 0}
+
+// Always in [0, modulus), even for a negative multiplier or initial value.
+// modulus must be positive
+func (h Hasher) Hash(s string) (hash int) {
+	hash = h.reduce(h.initial)
+	for _, c := range s {
+		hash += int(c)
+		hash *= h.multiplier
+		hash = h.reduce(hash)
+	}
+	return hash
+}
+
+func (h Hasher) reduce(n int) int {
+	return ((n % h.modulus) + h.modulus) % h.modulus
+}
+
+// Every distinct label in the steps, in the order they first show up
+func getLabels(steps []string) (labels []string) {
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		label := parseStep(step).label
+		if !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+type HashAnalysis struct {
+	hasher  Hasher
+	buckets [][]string
+	// Labels that share a bucket with an earlier label
+	collisions int
+	chiSquared float64
+}
+
+func (h Hasher) Analyse(labels []string) (a HashAnalysis) {
+	a.hasher = h
+	a.buckets = make([][]string, h.modulus)
+	for _, label := range labels {
+		idx := h.Hash(label)
+		a.buckets[idx] = append(a.buckets[idx], label)
+	}
+
+	// Against a uniform spread over every bucket, meaningless without labels
+	if len(labels) == 0 {
+		return a
+	}
+	expected := float64(len(labels)) / float64(h.modulus)
+	for _, bucket := range a.buckets {
+		if len(bucket) > 1 {
+			a.collisions += len(bucket) - 1
+		}
+		diff := float64(len(bucket)) - expected
+		a.chiSquared += diff * diff / expected
+	}
+
+	return a
+}
+
+func (a HashAnalysis) String() string {
+	var buff strings.Builder
+	h := a.hasher
+	fmt.Fprintf(&buff, "multiplier %d, modulus %d, initial %d\n", h.multiplier, h.modulus, h.initial)
+
+	// How many buckets hold 0, 1, 2... labels
+	sizes := []int{}
+	for _, bucket := range a.buckets {
+		for len(sizes) <= len(bucket) {
+			sizes = append(sizes, 0)
+		}
+		sizes[len(bucket)]++
+	}
+	for size, count := range sizes {
+		fmt.Fprintf(&buff, "  %d buckets with %d labels\n", count, size)
+	}
+
+	fmt.Fprintf(&buff, "  collisions: %d\n", a.collisions)
+	fmt.Fprintf(&buff, "  chi-squared: %.2f (%d degrees of freedom)\n", a.chiSquared, h.modulus-1)
+
+	for i, bucket := range a.buckets {
+		if len(bucket) > 1 {
+			fmt.Fprintf(&buff, "  box %d: %s\n", i, strings.Join(bucket, " -> "))
+		}
+	}
+
+	return buff.String()
+}
+
+// Tries every multiplier from 1 to maxMultiplier and returns the best
+// ones, fewest collisions first, then lowest chi-squared
+func (h Hasher) SearchMultipliers(labels []string, maxMultiplier, top int) []HashAnalysis {
+	results := make([]HashAnalysis, 0, maxMultiplier)
+	for m := 1; m <= maxMultiplier; m++ {
+		candidate := h
+		candidate.multiplier = m
+		results = append(results, candidate.Analyse(labels))
+	}
+
+	slices.SortStableFunc(results, func(a, b HashAnalysis) int {
+		if a.collisions != b.collisions {
+			return a.collisions - b.collisions
+		}
+		switch {
+		case a.chiSquared < b.chiSquared:
+			return -1
+		case a.chiSquared > b.chiSquared:
+			return 1
+		}
+		return 0
+	})
+
+	return results[:min(top, len(results))]
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type entry[V any] struct {
+	label string
+	value V
+}
+
+// The HASHMAP from part B. Labels are spread over boxes by their hash,
+// and each box keeps its labels in insertion order. Setting a label that
+// is already there keeps its place
+type HashMap[V any] struct {
+	hasher Hasher
+	boxes  [][]entry[V]
+	len    int
+}
+
+func NewHashMap[V any](hasher Hasher) *HashMap[V] {
+	return &HashMap[V]{hasher: hasher, boxes: make([][]entry[V], hasher.modulus)}
+}
+
+func (m *HashMap[V]) find(label string) (box, slot int) {
+	box = m.hasher.Hash(label)
+	for i, e := range m.boxes[box] {
+		if e.label == label {
+			return box, i
+		}
+	}
+	return box, -1
+}
+
+func (m *HashMap[V]) Get(label string) (value V, ok bool) {
+	box, slot := m.find(label)
+	if slot < 0 {
+		return value, false
+	}
+	return m.boxes[box][slot].value, true
+}
+
+func (m *HashMap[V]) Set(label string, value V) {
+	box, slot := m.find(label)
+	if slot >= 0 {
+		m.boxes[box][slot].value = value
+		return
+	}
+	m.boxes[box] = append(m.boxes[box], entry[V]{label, value})
+	m.len++
+}
+
+func (m *HashMap[V]) Delete(label string) bool {
+	box, slot := m.find(label)
+	if slot < 0 {
+		return false
+	}
+	m.boxes[box] = append(m.boxes[box][:slot], m.boxes[box][slot+1:]...)
+	m.len--
+	return true
+}
+
+func (m *HashMap[V]) Len() int {
+	return m.len
+}
+
+// Calls fn for every label, box by box and in insertion order within a
+// box, until fn returns false
+func (m *HashMap[V]) Each(fn func(box, slot int, label string, value V) bool) {
+	for b, entries := range m.boxes {
+		for s, e := range entries {
+			if !fn(b, s, e.label, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (m *HashMap[V]) box(box int) []entry[V] {
+	return m.boxes[box]
+}
+
+// Sum of (box + 1) * (slot + 1) * value for every lens
+func FocusingPower(m *HashMap[int]) (power int) {
+	m.Each(func(box, slot int, _ string, focal int) bool {
+		power += (box + 1) * (slot + 1) * focal
+		return true
+	})
+	return power
+}
+
+// Applies one `label=N` or `label-` step
+func (m *HashMap[V]) Apply(s Step, value V) {
+	if s.op == '=' {
+		m.Set(s.label, value)
+	} else {
+		m.Delete(s.label)
+	}
+}
+
+// Steps that rebuild the map from scratch, in `label=N` format
+func (m *HashMap[V]) Steps() []string {
+	steps := make([]string, 0, m.len)
+	m.Each(func(_, _ int, label string, value V) bool {
+		steps = append(steps, fmt.Sprintf("%s=%v", label, value))
+		return true
+	})
+	return steps
+}
+
+func (m *HashMap[V]) String() string {
+	return strings.Join(m.Steps(), ",")
+}
+
+func ReplaySteps(steps []string, hasher Hasher) *HashMap[int] {
+	m := NewHashMap[int](hasher)
+	for _, step := range steps {
+		s := parseStep(step)
+		m.Apply(s, s.focal)
+	}
+	return m
+}
+
+// Same labels and values, in the same boxes and order
+func EqualMaps[V comparable](a, b *HashMap[V]) bool {
+	if a.Len() != b.Len() || len(a.boxes) != len(b.boxes) {
+		return false
+	}
+	for i := range a.boxes {
+		if len(a.boxes[i]) != len(b.boxes[i]) {
+			return false
+		}
+		for j := range a.boxes[i] {
+			if a.boxes[i][j] != b.boxes[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
@@ -14,38 +15,6 @@ type Step struct {
 	focal int
 }
 
-type Lens struct {
-	label string
-	focal int
-}
-
-type Box []Lens
-
-func (b Box) Add(l Lens) Box {
-  for i, l1 := range b {
-    if l1.label == l.label {
-      b[i] = l
-      return b
-    }
-  }
-	return append(b, l)
-}
-
-func (b Box) Remove(label string) Box {
-	b1 := make(Box, len(b))
-
-	count := 0
-
-	for _, l := range b {
-		if l.label != label {
-			b1[count] = l
-			count++
-		}
-	}
-
-	return b1[:count]
-}
-
 func parseInput(filename string) []string {
 	buff, _ := os.ReadFile(filename)
 	buff = bytes.TrimSpace(buff)
@@ -91,22 +60,7 @@ func parseStep(step string) Step {
 }
 
 func HASH(s string) (hash int) {
-	for _, c := range s {
-		ascii := int(c)
-		hash += ascii
-		hash *= 17
-		hash = hash % 256
-	}
-	return hash
-}
-
-func getScore(boxes []Box) (score int) {
-  for i, box := range boxes {
-    for j, lens := range box {
-      score += (i+1) * (j+1) * lens.focal
-    }
-  }
-  return
+	return DefaultHasher.Hash(s)
 }
 
 func solveA(steps []string) (soln int) {
@@ -116,31 +70,30 @@ func solveA(steps []string) (soln int) {
 	return
 }
 
-func solveB(steps []string) (soln int) {
-  box := [256]Box{}
-  for i := range box {
-    box[i] = make([]Lens, 0)
-  }
-
-  for _, step := range steps {
-    s := parseStep(step)
-    bIdx := HASH(s.label)
-    b := box[bIdx]
-
-    if s.op == '=' {
-      b = b.Add(Lens{s.label, s.focal})
-    } else {
-      b = b.Remove(s.label)
-    }
-    // fmt.Println(b)
-    box[bIdx] = b
-  }
-
-	return getScore(box[:])
+func solveB(steps []string, hasher Hasher) (soln int) {
+	return FocusingPower(ReplaySteps(steps, hasher))
 }
 
+var inputFile = flag.String("input", "day15/in.txt", "puzzle input")
+var multiplier = flag.Int("multiplier", DefaultHasher.multiplier, "HASH multiplier for part B")
+var modulus = flag.Int("modulus", DefaultHasher.modulus, "HASH modulus, ie. the number of boxes, for part B")
+var initial = flag.Int("initial", DefaultHasher.initial, "HASH initial value for part B")
+var analyse = flag.Bool("analyse", false, "report how the labels spread over the boxes")
+var dump = flag.Bool("dump", false, "print the final boxes as steps that rebuild them")
+var compare = flag.String("compare", "", "check whether the steps in this file end in the same boxes")
+var trace = flag.String("trace", "", "print the box touched by every step, as json or text")
+var traceLabel = flag.String("trace-label", "", "only trace the steps on this label")
+var traceBox = flag.Int("trace-box", -1, "only trace the steps on this box")
+var search = flag.Int("search", 0, "also try every multiplier up to this one and list the best, 0 to skip")
+
 func main() {
-	steps := parseInput("day15/in.txt")
+	flag.Parse()
+	if *modulus <= 0 {
+		fmt.Println("modulus must be positive, got", *modulus)
+		os.Exit(1)
+	}
+	steps := parseInput(*inputFile)
+	hasher := Hasher{*multiplier, *modulus, *initial}
 
 	// Sanity test
 	// hash := HASH("HASH")
@@ -149,6 +102,41 @@ func main() {
 	solnA := solveA(steps)
 	fmt.Println("A:", solnA)
 
-  solnB := solveB(steps)
+  solnB := solveB(steps, hasher)
   fmt.Println("B:", solnB)
+
+	if *trace != "" {
+		entries := filterTrace(traceSteps(steps, hasher), *traceLabel, *traceBox)
+		switch *trace {
+		case "json":
+			if err := writeTraceJSON(os.Stdout, entries); err != nil {
+				fmt.Println(err)
+			}
+		case "text":
+			writeTraceTable(os.Stdout, entries)
+		default:
+			fmt.Println("unknown trace format:", *trace)
+			os.Exit(1)
+		}
+	}
+
+	if *dump {
+		fmt.Println(ReplaySteps(steps, hasher))
+	}
+
+	if *compare != "" {
+		other := ReplaySteps(parseInput(*compare), hasher)
+		fmt.Println("Same boxes:", EqualMaps(ReplaySteps(steps, hasher), other))
+	}
+
+	if *analyse {
+		fmt.Print(hasher.Analyse(getLabels(steps)))
+	}
+
+	if *search > 0 {
+		fmt.Println("Best multipliers:")
+		for _, a := range hasher.SearchMultipliers(getLabels(steps), *search, 5) {
+			fmt.Printf("  %d: %d collisions, chi-squared %.2f\n", a.hasher.multiplier, a.collisions, a.chiSquared)
+		}
+	}
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type TraceLens struct {
+	Label string `json:"label"`
+	Focal int    `json:"focal"`
+}
+
+// The box a step touched, as it was right after the step
+type TraceEntry struct {
+	Index  int         `json:"index"`
+	Step   string      `json:"step"`
+	Label  string      `json:"label"`
+	Box    int         `json:"box"`
+	Lenses []TraceLens `json:"lenses"`
+}
+
+func traceSteps(steps []string, hasher Hasher) []TraceEntry {
+	m := NewHashMap[int](hasher)
+	trace := make([]TraceEntry, len(steps))
+
+	for i, step := range steps {
+		s := parseStep(step)
+		m.Apply(s, s.focal)
+
+		box := hasher.Hash(s.label)
+		lenses := []TraceLens{}
+		for _, e := range m.box(box) {
+			lenses = append(lenses, TraceLens{e.label, e.value})
+		}
+		trace[i] = TraceEntry{i + 1, step, s.label, box, lenses}
+	}
+
+	return trace
+}
+
+// Keeps the steps on one label, one box, or both. An empty label or a
+// negative box matches everything
+func filterTrace(trace []TraceEntry, label string, box int) (filtered []TraceEntry) {
+	for _, t := range trace {
+		if (label == "" || t.Label == label) && (box < 0 || t.Box == box) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+func writeTraceJSON(w io.Writer, trace []TraceEntry) error {
+	enc := json.NewEncoder(w)
+	for _, t := range trace {
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// One line per step, in the same style as the puzzle's walkthrough
+func writeTraceTable(w io.Writer, trace []TraceEntry) {
+	fmt.Fprintln(w, "#\tStep\tBox\tLenses")
+	for _, t := range trace {
+		lenses := make([]string, len(t.Lenses))
+		for i, l := range t.Lenses {
+			lenses[i] = fmt.Sprintf("[%s %d]", l.Label, l.Focal)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", t.Index, t.Step, t.Box, strings.Join(lenses, " "))
+	}
+}
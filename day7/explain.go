@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type cardGroup struct {
+	card  rune
+	count int
+}
+
+// Groups the non-wild cards of a hand, biggest group first and the
+// higher card first among groups of the same size
+func getCardGroups(hand []rune, rules RuleSet) (groups []cardGroup, wildCount int) {
+	for _, r := range hand {
+		if rules.isWild(r) {
+			wildCount++
+			continue
+		}
+		idx := slices.IndexFunc(groups, func(g cardGroup) bool { return g.card == r })
+		if idx < 0 {
+			groups = append(groups, cardGroup{r, 1})
+		} else {
+			groups[idx].count++
+		}
+	}
+
+	sortGroups(groups, rules)
+	return groups, wildCount
+}
+
+func sortGroups(groups []cardGroup, rules RuleSet) {
+	slices.SortStableFunc(groups, func(a, b cardGroup) int {
+		if a.count != b.count {
+			return b.count - a.count
+		}
+		return rules.getCardValue(b.card) - rules.getCardValue(a.card)
+	})
+}
+
+func groupCounts(groups []cardGroup) []int {
+	counts := make([]int, len(groups))
+	for i, g := range groups {
+		counts[i] = g.count
+	}
+	return counts
+}
+
+// Cards a wild can turn into that are not already in the hand, best first
+func (rules RuleSet) freshCards(groups []cardGroup) []rune {
+	fresh := []rune{}
+	cards := []rune(rules.cards)
+	for i := len(cards) - 1; i >= 0; i-- {
+		c := cards[i]
+		inHand := slices.ContainsFunc(groups, func(g cardGroup) bool { return g.card == c })
+		if !rules.isWild(c) && !inHand {
+			fresh = append(fresh, c)
+		}
+	}
+	return fresh
+}
+
+// Same search as bestCategory, but keeps track of which card each wild
+// was turned into. Returns the substitutions in the order the wilds
+// appear in the hand
+func (rules RuleSet) bestSubstitution(hand []rune) (handType int, subs []rune) {
+	groups, wildCount := getCardGroups(hand, rules)
+
+	var search func(groups []cardGroup, wildCount int) (int, []rune)
+	search = func(groups []cardGroup, wildCount int) (int, []rune) {
+		if wildCount == 0 {
+			return rules.getCategory(groupCounts(groups)), []rune{}
+		}
+
+		best, bestSubs := HandUnknown, []rune(nil)
+		try := func(next []cardGroup, card rune) {
+			sortGroups(next, rules)
+			handType, subs := search(next, wildCount-1)
+			if handType > best {
+				best, bestSubs = handType, append([]rune{card}, subs...)
+			}
+		}
+
+		for i := range groups {
+			if i > 0 && groups[i].count == groups[i-1].count {
+				continue
+			}
+			next := slices.Clone(groups)
+			next[i].count++
+			try(next, groups[i].card)
+		}
+
+		if fresh := rules.freshCards(groups); len(fresh) > 0 {
+			try(append(slices.Clone(groups), cardGroup{fresh[0], 1}), fresh[0])
+		}
+
+		return best, bestSubs
+	}
+
+	return search(groups, wildCount)
+}
+
+func substitute(hand []rune, subs []rune, rules RuleSet) []rune {
+	out := slices.Clone(hand)
+	j := 0
+	for i, r := range out {
+		if rules.isWild(r) && j < len(subs) {
+			out[i] = subs[j]
+			j++
+		}
+	}
+	return out
+}
+
+// Lists every hand holding a wild card with the substitution that gives
+// its best type, next to the type it has when the wilds are plain cards
+func explainWilds(plays []Play, rules RuleSet) string {
+	plain := rules
+	plain.wild = ""
+
+	var buff strings.Builder
+	fmt.Fprintln(&buff, "Hand\tAs\tType\tPlain")
+	for _, p := range plays {
+		handType, subs := rules.bestSubstitution(p.hand)
+		if len(subs) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buff, "%s\t%s\t%s\t%s\n",
+			string(p.hand),
+			string(substitute(p.hand, subs, rules)),
+			rules.categoryName(handType),
+			plain.categoryName(getHandType(p.hand, plain)),
+		)
+	}
+	return buff.String()
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"cmp"
+	"flag"
 	"fmt"
 	"os"
+	"runtime"
 	"slices"
 	"strconv"
 )
@@ -12,73 +15,11 @@ type Play struct {
 	hand     []rune
 	bid      int
 	handType int
+	sortKey  int
 }
 
-const (
-	HandUnknown   = iota
-	HandHighCard  = iota
-	HandOnePair   = iota
-	HandTwoPair   = iota
-	HandThreeKind = iota
-	HandFullhouse = iota
-	HandFourKind  = iota
-	HandFiveKind  = iota
-)
-
-const (
-	CardWild  = iota
-	CardTwo   = iota
-	CardThree = iota
-	CardFour  = iota
-	CardFive  = iota
-	CardSix   = iota
-	CardSeven = iota
-	CardEight = iota
-	CardNine  = iota
-	CardTen   = iota
-	CardJack  = iota
-	CardQueen = iota
-	CardKing  = iota
-	CardAce   = iota
-	// CardWild  = iota
-)
-
-func getCardValue(r rune, isPartTwo bool) int {
-	switch r {
-	case '2':
-		return CardTwo
-	case '3':
-		return CardThree
-	case '4':
-		return CardFour
-	case '5':
-		return CardFive
-	case '6':
-		return CardSix
-	case '7':
-		return CardSeven
-	case '8':
-		return CardEight
-	case '9':
-		return CardNine
-	case 'T':
-		return CardTen
-	case 'J':
-		if isPartTwo {
-			return CardWild
-		} else {
-			return CardJack
-		}
-	case 'Q':
-		return CardQueen
-	case 'K':
-		return CardKing
-	case 'A':
-		return CardAce
-	default:
-		return -1
-	}
-}
+// Hand types are indices into the rule set's categories, counting from 1
+const HandUnknown = 0
 
 func parseInput(filename string) []Play {
 	buff, _ := os.ReadFile(filename)
@@ -90,77 +31,23 @@ func parseInput(filename string) []Play {
 		parts := bytes.Fields(line)
 		hand := bytes.Runes(parts[0])
 		bid, _ := strconv.Atoi(string(parts[1]))
-		plays[i] = Play{hand, bid, HandUnknown}
+		plays[i] = Play{hand, bid, HandUnknown, 0}
 	}
 
 	return plays
 }
 
-func getHandType(hand []rune, isPartTwo bool) int {
-	counts := make(map[rune]int)
-	wildCount := 0
-
-	for _, r := range hand {
-		if r == 'J' && isPartTwo {
-			wildCount++
-		} else {
-			counts[r]++
-		}
-	}
-
-	// Add wilds to the card with the most count
-	maxCard := 'A'
-	maxCardCount := counts['A']
-	for card, count := range counts {
-		if count > maxCardCount {
-			maxCard = card
-			maxCardCount = count
-		}
-	}
-  counts[maxCard] += wildCount
-
-	haveFive := false
-	haveFour := false
-	haveThree := false
-	havePair := false
-	pairCount := 0
-
-	for _, count := range counts {
-		if count == 5 {
-			haveFive = true
-		} else if count == 4 {
-			haveFour = true
-		} else if count == 3 {
-			haveThree = true
-		} else if count == 2 {
-			havePair = true
-			pairCount++
-		}
-	}
-
-	if haveFive {
-		return HandFiveKind
-	} else if haveFour {
-		return HandFourKind
-	} else if haveThree && havePair {
-		return HandFullhouse
-	} else if haveThree && !havePair {
-		return HandThreeKind
-	} else if havePair && pairCount == 2 {
-		return HandTwoPair
-	} else if havePair && pairCount == 1 {
-		return HandOnePair
-	} else {
-		return HandHighCard
-	}
+func getHandType(hand []rune, rules RuleSet) int {
+	counts, wildCount := getCounts(hand, rules)
+	return rules.bestCategory(counts, wildCount)
 }
 
-func compareHands(a, b []rune, isPartTwo bool) int {
+func compareHands(a, b []rune, rules RuleSet) int {
 	length := min(len(a), len(b))
 
 	for i := 0; i < length; i++ {
-		valA := getCardValue(a[i], isPartTwo)
-		valB := getCardValue(b[i], isPartTwo)
+		valA := rules.getCardValue(a[i])
+		valB := rules.getCardValue(b[i])
 		if valA == valB {
 			continue
 		} else {
@@ -171,35 +58,142 @@ func compareHands(a, b []rune, isPartTwo bool) int {
 	return 0
 }
 
-func solveA(plays []Play, isPartTwo bool) int {
-	slices.SortFunc(plays, func(a, b Play) int {
-		handA := getHandType(a.hand, isPartTwo)
-		handB := getHandType(b.hand, isPartTwo)
+// Packs the hand type and card values into a single base-(len(cards)+1)
+// number, so that plays can be ordered with a plain integer comparison
+func getSortKey(hand []rune, handType int, rules RuleSet) int {
+	base := len(rules.cards) + 1
+	key := handType
+	for _, r := range hand {
+		key = key*base + rules.getCardValue(r)
+	}
+	return key
+}
 
-		if handA != handB {
-			return handA - handB
-		} else {
-			return compareHands(a.hand, b.hand, isPartTwo)
+func classifyPlays(plays []Play, rules RuleSet) error {
+	for i, p := range plays {
+		if err := rules.validateHand(p.hand); err != nil {
+			return err
 		}
+
+		plays[i].handType = getHandType(p.hand, rules)
+		plays[i].sortKey = getSortKey(p.hand, plays[i].handType, rules)
+	}
+	return nil
+}
+
+func solveA(plays []Play, rules RuleSet) (int, error) {
+	if err := classifyPlays(plays, rules); err != nil {
+		return 0, err
+	}
+
+	slices.SortFunc(plays, func(a, b Play) int {
+		return cmp.Compare(a.sortKey, b.sortKey)
 	})
 
 	acc := 0
 
-	fmt.Println("Hand\tBid\tType\tRank")
+	if *verbose {
+		fmt.Println("Hand\tBid\tType\tRank")
+	}
 	for i, p := range plays {
-		fmt.Printf("%s\t%d\t%d\t%d\n", string(p.hand), p.bid, getHandType(p.hand, isPartTwo), i+1)
+		if *verbose {
+			fmt.Printf("%s\t%d\t%s\t%d\n", string(p.hand), p.bid, rules.categoryName(p.handType), i+1)
+		}
 		acc += (i + 1) * p.bid
 	}
 
-	return acc
+	return acc, nil
 }
 
+var ruleName = flag.String("rules", "", "also score the input with a named rule set (jack-queen, six-card, ...)")
+var inputFile = flag.String("input", "day7/in.txt", "puzzle input")
+var verbose = flag.Bool("v", false, "print every hand with its type and rank")
+var explain = flag.Bool("explain", false, "show the best substitution for every hand with jokers")
+
+var simTrials = flag.Int("simulate", 0, "number of Monte Carlo trials to run, 0 to skip")
+var simHand = flag.String("hand", "", "hand to estimate the win probability of")
+var simOpponents = flag.Int("opponents", 1, "number of opponents the hand plays against")
+var simSeed = flag.Int64("seed", 1, "simulator seed")
+var simWorkers = flag.Int("workers", runtime.NumCPU(), "simulator workers")
+var simReplacement = flag.Bool("replacement", false, "deal with replacement")
+var simJokers = flag.Bool("jokers", false, "play the wild cards of the rule set as jokers")
+
 func main() {
-	plays := parseInput("day7/in.txt")
+	flag.Parse()
+
+	if *simTrials > 0 {
+		simulate()
+		return
+	}
 
-	solnA := solveA(plays, false)
+	plays := parseInput(*inputFile)
+
+	solnA, err := solveA(plays, RulesStandard)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	fmt.Println("Solution A:", solnA)
 
-	solnB := solveA(plays, true)
+	solnB, err := solveA(plays, RulesJoker)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	fmt.Println("Solution B:", solnB)
+
+	if *explain {
+		fmt.Print(explainWilds(plays, RulesJoker))
+	}
+
+	if *ruleName != "" {
+		rules, ok := getRuleSet(*ruleName)
+		if !ok {
+			fmt.Println("unknown rule set:", *ruleName)
+			os.Exit(1)
+		}
+		soln, err := solveA(plays, rules)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("Solution %s: %d\n", rules.name, soln)
+
+		if *explain {
+			fmt.Print(explainWilds(plays, rules))
+		}
+	}
+}
+
+func simulate() {
+	rules := RulesJoker
+	if *ruleName != "" {
+		var ok bool
+		if rules, ok = getRuleSet(*ruleName); !ok {
+			fmt.Println("unknown rule set:", *ruleName)
+			os.Exit(1)
+		}
+	}
+
+	sim := Simulator{
+		deck:    Deck{rules: rules, copies: 4, replacement: *simReplacement, jokers: *simJokers},
+		seed:    *simSeed,
+		workers: *simWorkers,
+	}
+
+	freqs, err := sim.HandFrequencies(*simTrials)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Print(sim.frequencyString(freqs))
+
+	if *simHand != "" {
+		win, tie, err := sim.WinProbability([]rune(*simHand), *simOpponents, *simTrials)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s vs %d: win %.4f tie %.4f\n", *simHand, *simOpponents, win, tie)
+	}
 }
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// A hand category matches any hand whose sorted card counts are at least
+// its signature, position by position. Eg. {3, 2} is a full house.
+type HandCategory struct {
+	name      string
+	signature []int
+}
+
+type RuleSet struct {
+	name string
+	// Cards in ascending order of value
+	cards string
+	// Wild cards rank below every other card and can stand in for any of them
+	wild       string
+	handSize   int
+	categories []HandCategory // weakest first
+}
+
+var standardCategories = []HandCategory{
+	{"HighCard", []int{1}},
+	{"OnePair", []int{2}},
+	{"TwoPair", []int{2, 2}},
+	{"ThreeKind", []int{3}},
+	{"Fullhouse", []int{3, 2}},
+	{"FourKind", []int{4}},
+	{"FiveKind", []int{5}},
+}
+
+var sixCardCategories = []HandCategory{
+	{"HighCard", []int{1}},
+	{"OnePair", []int{2}},
+	{"TwoPair", []int{2, 2}},
+	{"ThreePair", []int{2, 2, 2}},
+	{"ThreeKind", []int{3}},
+	{"Fullhouse", []int{3, 2}},
+	{"TwoTriple", []int{3, 3}},
+	{"FourKind", []int{4}},
+	{"FourPair", []int{4, 2}},
+	{"FiveKind", []int{5}},
+	{"SixKind", []int{6}},
+}
+
+var (
+	RulesStandard  = RuleSet{"standard", "23456789TJQKA", "", 5, standardCategories}
+	RulesJoker     = RuleSet{"joker", "23456789TJQKA", "J", 5, standardCategories}
+	RulesJackQueen = RuleSet{"jack-queen", "23456789TJQKA", "JQ", 5, standardCategories}
+	RulesSixCard   = RuleSet{"six-card", "23456789TJQKA", "", 6, sixCardCategories}
+	RulesSixJoker  = RuleSet{"six-joker", "23456789TJQKA", "J", 6, sixCardCategories}
+)
+
+var ruleSets = []RuleSet{RulesStandard, RulesJoker, RulesJackQueen, RulesSixCard, RulesSixJoker}
+
+func getRuleSet(name string) (RuleSet, bool) {
+	for _, rules := range ruleSets {
+		if rules.name == name {
+			return rules, true
+		}
+	}
+	return RuleSet{}, false
+}
+
+func (rules RuleSet) isWild(r rune) bool {
+	for _, w := range rules.wild {
+		if w == r {
+			return true
+		}
+	}
+	return false
+}
+
+// Wild cards are worth 0, the rest are ranked from 1 in alphabet order.
+// Cards outside the alphabet are worth -1
+func (rules RuleSet) getCardValue(r rune) int {
+	if rules.isWild(r) {
+		return 0
+	}
+	for i, c := range rules.cards {
+		if c == r {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func (rules RuleSet) validateHand(hand []rune) error {
+	if len(hand) != rules.handSize {
+		return fmt.Errorf("hand %s does not have %d cards", string(hand), rules.handSize)
+	}
+	for _, r := range hand {
+		if rules.getCardValue(r) < 0 {
+			return fmt.Errorf("hand %s has unknown card %c", string(hand), r)
+		}
+	}
+	return nil
+}
+
+// Returns the index of the strongest category matching the counts,
+// offset by one so that HandUnknown stays 0
+func (rules RuleSet) getCategory(counts []int) int {
+	best := HandUnknown
+	for i, category := range rules.categories {
+		if matchesSignature(counts, category.signature) {
+			best = i + 1
+		}
+	}
+	return best
+}
+
+func (rules RuleSet) categoryName(handType int) string {
+	if handType <= HandUnknown || handType > len(rules.categories) {
+		return "Unknown"
+	}
+	return rules.categories[handType-1].name
+}
+
+// counts must be sorted in descending order
+func matchesSignature(counts, signature []int) bool {
+	if len(counts) < len(signature) {
+		return false
+	}
+	for i := range signature {
+		if counts[i] < signature[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getCounts(hand []rune, rules RuleSet) (counts []int, wildCount int) {
+	cardCounts := make(map[rune]int)
+	for _, r := range hand {
+		if rules.isWild(r) {
+			wildCount++
+		} else {
+			cardCounts[r]++
+		}
+	}
+
+	counts = make([]int, 0, len(cardCounts))
+	for _, count := range cardCounts {
+		counts = append(counts, count)
+	}
+	sortCounts(counts)
+
+	return counts, wildCount
+}
+
+func sortCounts(counts []int) {
+	slices.SortFunc(counts, func(a, b int) int { return b - a })
+}
+
+// Tries every way of distributing the wilds over the existing card
+// groups (or as new groups) and returns the best category reachable
+func (rules RuleSet) bestCategory(counts []int, wildCount int) int {
+	if wildCount == 0 {
+		return rules.getCategory(counts)
+	}
+
+	best := HandUnknown
+	for i := range counts {
+		// Groups of equal size give the same result
+		if i > 0 && counts[i] == counts[i-1] {
+			continue
+		}
+		next := slices.Clone(counts)
+		next[i]++
+		sortCounts(next)
+		best = max(best, rules.bestCategory(next, wildCount-1))
+	}
+
+	next := append(slices.Clone(counts), 1)
+	best = max(best, rules.bestCategory(next, wildCount-1))
+
+	return best
+}
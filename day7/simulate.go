@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Deck struct {
+	rules RuleSet
+	// Copies of each card in the deck, unused when dealing with replacement
+	copies      int
+	replacement bool
+	jokers      bool
+}
+
+// Jokers off means the wild cards of the rule set play as normal cards
+func (deck Deck) getRules() RuleSet {
+	rules := deck.rules
+	if !deck.jokers {
+		rules.wild = ""
+	}
+	return rules
+}
+
+func (deck Deck) cards() []rune {
+	cards := make([]rune, 0, len(deck.rules.cards)*deck.copies)
+	for _, c := range deck.rules.cards {
+		for i := 0; i < deck.copies; i++ {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
+
+// Removes one copy of every card in hand from the deck
+func removeCards(cards []rune, hand []rune) []rune {
+	cards = slices.Clone(cards)
+	for _, r := range hand {
+		if idx := slices.Index(cards, r); idx >= 0 {
+			cards = slices.Delete(cards, idx, idx+1)
+		}
+	}
+	return cards
+}
+
+// Deals n cards. Without replacement the dealt cards are swapped to the
+// front of the pile starting at offset, so the caller can keep dealing
+// from offset+n
+func (deck Deck) deal(rng *rand.Rand, pile []rune, offset, n int) []rune {
+	hand := make([]rune, n)
+	for i := range hand {
+		if deck.replacement {
+			hand[i] = pile[rng.Intn(len(pile))]
+		} else {
+			j := offset + i + rng.Intn(len(pile)-offset-i)
+			pile[offset+i], pile[j] = pile[j], pile[offset+i]
+			hand[i] = pile[offset+i]
+		}
+	}
+	return hand
+}
+
+type Simulator struct {
+	deck    Deck
+	seed    int64
+	workers int
+}
+
+// Trials are split into this many chunks no matter how many workers
+// there are, so the same seed always gives the same result
+const simChunks = 64
+
+// Mixes the simulator seed and a chunk index with splitmix64, so that
+// neighbouring seeds don't share chunk streams
+func chunkSeed(seed int64, chunk int) int64 {
+	z := uint64(seed)*simChunks + uint64(chunk) + 0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
+
+// Every chunk gets its own RNG seeded from the simulator seed and the
+// chunk index. Workers pick up chunks in whatever order, so results
+// depend neither on scheduling nor on the number of workers
+func (sim Simulator) run(trials int, trial func(rng *rand.Rand, pile []rune, counts []int), pile []rune, size int) []int {
+	results := make([][]int, simChunks)
+	chunks := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < max(sim.workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				rng := rand.New(rand.NewSource(chunkSeed(sim.seed, c)))
+				localPile := slices.Clone(pile)
+				counts := make([]int, size)
+				n := trials / simChunks
+				if c < trials%simChunks {
+					n++
+				}
+				for i := 0; i < n; i++ {
+					trial(rng, localPile, counts)
+				}
+				results[c] = counts
+			}
+		}()
+	}
+	for c := 0; c < simChunks; c++ {
+		chunks <- c
+	}
+	close(chunks)
+	wg.Wait()
+
+	total := make([]int, size)
+	for _, counts := range results {
+		for i, c := range counts {
+			total[i] += c
+		}
+	}
+	return total
+}
+
+// Estimated frequency of every hand type, indexed by hand type
+func (sim Simulator) HandFrequencies(trials int) ([]float64, error) {
+	rules := sim.deck.getRules()
+	pile := sim.deck.cards()
+	if !sim.deck.replacement && len(pile) < rules.handSize {
+		return nil, fmt.Errorf("deck of %d cards is smaller than a hand of %d", len(pile), rules.handSize)
+	}
+
+	counts := sim.run(trials, func(rng *rand.Rand, pile []rune, counts []int) {
+		hand := sim.deck.deal(rng, pile, 0, rules.handSize)
+		counts[getHandType(hand, rules)]++
+	}, pile, len(rules.categories)+1)
+
+	freqs := make([]float64, len(counts))
+	for i, c := range counts {
+		freqs[i] = float64(c) / float64(trials)
+	}
+	return freqs, nil
+}
+
+// Estimated chance that hand beats (or ties with the best of) the given
+// number of opponents dealt from the rest of the deck
+func (sim Simulator) WinProbability(hand []rune, opponents, trials int) (win, tie float64, err error) {
+	const (
+		outcomeLoss = iota
+		outcomeTie
+		outcomeWin
+	)
+
+	rules := sim.deck.getRules()
+	if err := rules.validateHand(hand); err != nil {
+		return 0, 0, err
+	}
+	handType := getHandType(hand, rules)
+	pile := sim.deck.cards()
+	if !sim.deck.replacement {
+		pile = removeCards(pile, hand)
+		if len(pile) < opponents*rules.handSize {
+			return 0, 0, fmt.Errorf("%d cards left, not enough for %d opponents", len(pile), opponents)
+		}
+	}
+
+	counts := sim.run(trials, func(rng *rand.Rand, pile []rune, counts []int) {
+		outcome := outcomeWin
+		for o := 0; o < opponents && outcome != outcomeLoss; o++ {
+			other := sim.deck.deal(rng, pile, o*rules.handSize, rules.handSize)
+			otherType := getHandType(other, rules)
+
+			diff := handType - otherType
+			if diff == 0 {
+				diff = compareHands(hand, other, rules)
+			}
+			if diff < 0 {
+				outcome = outcomeLoss
+			} else if diff == 0 {
+				outcome = outcomeTie
+			}
+		}
+		counts[outcome]++
+	}, pile, 3)
+
+	return float64(counts[outcomeWin]) / float64(trials), float64(counts[outcomeTie]) / float64(trials), nil
+}
+
+func (sim Simulator) frequencyString(freqs []float64) string {
+	rules := sim.deck.getRules()
+
+	var buff strings.Builder
+	fmt.Fprintln(&buff, "Type\tFrequency")
+	for handType := len(freqs) - 1; handType > HandUnknown; handType-- {
+		fmt.Fprintf(&buff, "%s\t%.6f\n", rules.categoryName(handType), freqs[handType])
+	}
+	return buff.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSimulatorReproducible(t *testing.T) {
+	deck := Deck{rules: RulesJoker, copies: 4, jokers: true}
+	one := Simulator{deck: deck, seed: 7, workers: 1}
+	many := Simulator{deck: deck, seed: 7, workers: 5}
+
+	a, err := one.HandFrequencies(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := many.HandFrequencies(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a, b) {
+		t.Errorf("frequencies differ with 1 and 5 workers: %v vs %v", a, b)
+	}
+
+	hand := []rune("KKJ22")
+	winA, tieA, err := one.WinProbability(hand, 3, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	winB, tieB, err := many.WinProbability(hand, 3, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if winA != winB || tieA != tieB {
+		t.Errorf("win probability differs with 1 and 5 workers: %v/%v vs %v/%v", winA, tieA, winB, tieB)
+	}
+}
+
+func TestSimulatorSeedsDiffer(t *testing.T) {
+	deck := Deck{rules: RulesJoker, copies: 4, jokers: true}
+	for seed := int64(1); seed < 4; seed++ {
+		a, err := Simulator{deck: deck, seed: seed, workers: 4}.HandFrequencies(5000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Simulator{deck: deck, seed: seed + 1, workers: 4}.HandFrequencies(5000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slices.Equal(a, b) {
+			t.Errorf("seeds %d and %d give the same frequencies %v", seed, seed+1, a)
+		}
+	}
+}
+
+func TestWinProbabilityRejectsBadHands(t *testing.T) {
+	sim := Simulator{deck: Deck{rules: RulesStandard, copies: 4}, seed: 1, workers: 2}
+	for _, hand := range []string{"KK", "KKKKKK", "KKXKK"} {
+		if _, _, err := sim.WinProbability([]rune(hand), 1, 10); err == nil {
+			t.Errorf("hand %s was not rejected", hand)
+		}
+	}
+
+	if _, _, err := sim.WinProbability([]rune("KKJ22"), 20, 10); err == nil {
+		t.Error("20 opponents were dealt from a 47 card deck")
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+func sortedNodes[V any](nodes map[string]V) []string {
+	names := make([]string, 0, len(nodes))
+	for node := range nodes {
+		names = append(names, node)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type GraphStats struct {
+	Components  [][]string          `json:"components"`
+	Reaches     map[string][]string `json:"reaches"`
+	Unreachable []string            `json:"unreachable"`
+}
+
+// Tarjan's algorithm. Components only list nodes defined in the map
+func stronglyConnected(nodes map[string][]string) (components [][]string) {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := []string{}
+
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range nodes[node] {
+			if _, ok := nodes[next]; !ok {
+				continue
+			}
+			if _, seen := index[next]; !seen {
+				visit(next)
+				lowLink[node] = min(lowLink[node], lowLink[next])
+			} else if onStack[next] {
+				lowLink[node] = min(lowLink[node], index[next])
+			}
+		}
+
+		if lowLink[node] == index[node] {
+			component := []string{}
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == node {
+					break
+				}
+			}
+			slices.Sort(component)
+			components = append(components, component)
+		}
+	}
+
+	for _, node := range sortedNodes(nodes) {
+		if _, seen := index[node]; !seen {
+			visit(node)
+		}
+	}
+
+	return components
+}
+
+func reachableFrom(nodes map[string][]string, start string) map[string]bool {
+	seen := map[string]bool{start: true}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range nodes[node] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+func getGraphStats(nodes map[string][]string, isStart, isEnd NodePredicate) (stats GraphStats) {
+	stats.Components = stronglyConnected(nodes)
+	stats.Reaches = make(map[string][]string)
+	stats.Unreachable = []string{}
+
+	reached := make(map[string]bool)
+	for _, start := range sortedNodes(nodes) {
+		if !isStart(start) {
+			continue
+		}
+		ends := []string{}
+		for node := range reachableFrom(nodes, start) {
+			reached[node] = true
+			if isEnd(node) {
+				ends = append(ends, node)
+			}
+		}
+		slices.Sort(ends)
+		stats.Reaches[start] = ends
+	}
+
+	for _, node := range sortedNodes(nodes) {
+		if !reached[node] {
+			stats.Unreachable = append(stats.Unreachable, node)
+		}
+	}
+
+	return stats
+}
+
+func writeDOT(w io.Writer, doc Map, isStart, isEnd NodePredicate) {
+	stats := getGraphStats(doc.nodes, isStart, isEnd)
+
+	fmt.Fprintln(w, "digraph network {")
+	fmt.Fprintf(w, "  // %d strongly connected components\n", len(stats.Components))
+	for _, start := range sortedNodes(stats.Reaches) {
+		fmt.Fprintf(w, "  // %s reaches %v\n", start, stats.Reaches[start])
+	}
+	fmt.Fprintf(w, "  // unreachable: %v\n", stats.Unreachable)
+
+	for _, node := range sortedNodes(doc.nodes) {
+		switch {
+		case slices.Contains(stats.Unreachable, node):
+			fmt.Fprintf(w, "  %q [color=gray, fontcolor=gray];\n", node)
+		case isStart(node):
+			fmt.Fprintf(w, "  %q [style=filled, fillcolor=palegreen];\n", node)
+		case isEnd(node):
+			fmt.Fprintf(w, "  %q [style=filled, fillcolor=salmon];\n", node)
+		default:
+			fmt.Fprintf(w, "  %q;\n", node)
+		}
+	}
+	for _, node := range sortedNodes(doc.nodes) {
+		for i, next := range doc.nodes[node] {
+			fmt.Fprintf(w, "  %q -> %q [label=%q];\n", node, next, string(doc.alphabet[i]))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func writeJSON(w io.Writer, doc Map, isStart, isEnd NodePredicate) error {
+	adjacency := make(map[string]map[string]string, len(doc.nodes))
+	for node, children := range doc.nodes {
+		adjacency[node] = make(map[string]string, len(children))
+		for i, next := range children {
+			adjacency[node][string(doc.alphabet[i])] = next
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Directions string                       `json:"directions"`
+		Nodes      map[string]map[string]string `json:"nodes"`
+		Stats      GraphStats                   `json:"stats"`
+	}{doc.directions, adjacency, getGraphStats(doc.nodes, isStart, isEnd)})
+}
@@ -1,65 +1,114 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 )
 
 type Map struct {
 	directions string
-	nodes      map[string][]string
+	// Child index selected by every direction
+	turns []int
+	// Direction characters in the order of the children they select
+	alphabet []rune
+	nodes    map[string][]string
 }
 
-func parseInput(filename string) (doc Map) {
-	buff, _ := os.ReadFile(filename)
+type NodePredicate func(node string) bool
+
+func nodeIs(name string) NodePredicate {
+	return func(node string) bool { return node == name }
+}
+
+func nodeHasSuffix(suffix string) NodePredicate {
+	return func(node string) bool { return strings.HasSuffix(node, suffix) }
+}
+
+func parseInput(filename string, alphabet string) (doc Map, err error) {
+	buff, err := os.ReadFile(filename)
+	if err != nil {
+		return doc, err
+	}
 	lines := strings.Split(strings.TrimSpace(string(buff)), "\n")
 
-	doc.directions = lines[0]
+	doc.directions = strings.TrimSpace(lines[0])
+	doc.alphabet = []rune(alphabet)
 	doc.nodes = make(map[string][]string, len(lines)-2)
 
-	re := regexp.MustCompile(`(\w{3}) = \((\w{3}), (\w{3})\)`)
+	for i, r := range doc.alphabet {
+		if slices.Contains(doc.alphabet[:i], r) {
+			return doc, fmt.Errorf("alphabet %s has %c more than once", alphabet, r)
+		}
+	}
 
-	for _, line := range lines[2:] {
+	for _, dir := range doc.directions {
+		turn := slices.Index(doc.alphabet, dir)
+		if turn < 0 {
+			return doc, fmt.Errorf("direction %c is not in alphabet %s", dir, alphabet)
+		}
+		doc.turns = append(doc.turns, turn)
+	}
+
+	re := regexp.MustCompile(`^\s*(\w+)\s*=\s*\((.*)\)\s*$`)
+
+	for i, line := range lines[2:] {
 		match := re.FindStringSubmatch(line)
-		node, left, right := match[1], match[2], match[3]
-		doc.nodes[node] = []string{left, right}
+		if match == nil {
+			return doc, fmt.Errorf("line %d: cannot parse %q", i+3, line)
+		}
+
+		children := strings.Split(match[2], ",")
+		for j := range children {
+			children[j] = strings.TrimSpace(children[j])
+		}
+		if len(children) != len(doc.alphabet) {
+			return doc, fmt.Errorf("line %d: node %s has %d children, want %d", i+3, match[1], len(children), len(doc.alphabet))
+		}
+
+		doc.nodes[match[1]] = children
 	}
 
-	return doc
+	return doc, nil
 }
 
-func nextDir(directions string, currIdx int) (nextIdx int) {
-	if currIdx == len(directions)-1 {
+func nextDir(turns []int, currIdx int) (nextIdx int) {
+	if currIdx == len(turns)-1 {
 		return 0
 	}
 	return currIdx + 1
 }
 
-func solveA(doc Map) (steps int) {
-	currNode := "AAA"
+// Follows the directions from start until a node satisfies isEnd
+func walk(doc Map, start string, isEnd NodePredicate) (steps int) {
+	currNode := start
 	currIdx := 0
 
-	for currNode != "ZZZ" {
-		steps++
-		currDir := doc.directions[currIdx]
-
-		if currDir == 'L' {
-			currNode = doc.nodes[currNode][0]
-		} else {
-			currNode = doc.nodes[currNode][1]
+	for !isEnd(currNode) {
+		children, ok := doc.nodes[currNode]
+		if !ok {
+			panic(fmt.Sprintf("node %s is not defined", currNode))
 		}
 
-		currIdx = nextDir(doc.directions, currIdx)
+		steps++
+		currNode = children[doc.turns[currIdx]]
+
+		currIdx = nextDir(doc.turns, currIdx)
 	}
 
 	return steps
 }
 
-func getStartingNodes(nodes map[string][]string) (startingNodes []string) {
-	for node, _ := range nodes {
-		if strings.HasSuffix(node, "A") {
+func solveA(doc Map, start string, isEnd NodePredicate) (steps int) {
+	return walk(doc, start, isEnd)
+}
+
+func getStartingNodes(nodes map[string][]string, isStart NodePredicate) (startingNodes []string) {
+	for _, node := range sortedNodes(nodes) {
+		if isStart(node) {
 			startingNodes = append(startingNodes, node)
 		}
 	}
@@ -82,28 +131,12 @@ func LCMMultiple(nums []int) (lcm int) {
 	return
 }
 
-func solveB(doc Map) int {
-	currNodes := getStartingNodes(doc.nodes)
+func solveB(doc Map, isStart, isEnd NodePredicate) int {
+	currNodes := getStartingNodes(doc.nodes, isStart)
 	nodeSteps := make([]int, len(currNodes))
 
 	for i, currNode := range currNodes {
-		currIdx := 0
-		nodeStep := 0
-
-		for !strings.HasSuffix(currNode, "Z") {
-			nodeStep++
-			currDir := doc.directions[currIdx]
-
-			if currDir == 'L' {
-				currNode = doc.nodes[currNode][0]
-			} else {
-				currNode = doc.nodes[currNode][1]
-			}
-
-			currIdx = nextDir(doc.directions, currIdx)
-		}
-
-		nodeSteps[i] = nodeStep
+		nodeSteps[i] = walk(doc, currNode, isEnd)
 	}
 
 	fmt.Println(nodeSteps)
@@ -111,13 +144,51 @@ func solveB(doc Map) int {
 	return LCMMultiple(nodeSteps)
 }
 
+var inputFile = flag.String("input", "day8/c.txt", "puzzle input")
+var dotFile = flag.String("dot", "", "write the node network as Graphviz DOT")
+var jsonFile = flag.String("json", "", "write the node network and its stats as JSON")
+var alphabet = flag.String("alphabet", "LR", "direction characters, one per child of a node")
+var startNode = flag.String("start", "AAA", "start node for part A")
+var endNode = flag.String("end", "ZZZ", "end node for part A")
+var startSuffix = flag.String("start-suffix", "A", "suffix of the start nodes for part B")
+var endSuffix = flag.String("end-suffix", "Z", "suffix of the end nodes for part B")
+
+func export(filename string, write func(f *os.File)) {
+	f, err := os.Create(filename)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	write(f)
+}
+
 func main() {
-	doc := parseInput("day8/c.txt")
+	flag.Parse()
+	doc, err := parseInput(*inputFile, *alphabet)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	isStart, isEnd := nodeHasSuffix(*startSuffix), nodeHasSuffix(*endSuffix)
+
+	if *dotFile != "" {
+		export(*dotFile, func(f *os.File) { writeDOT(f, doc, isStart, isEnd) })
+	}
+	if *jsonFile != "" {
+		export(*jsonFile, func(f *os.File) {
+			if err := writeJSON(f, doc, isStart, isEnd); err != nil {
+				fmt.Println(err)
+			}
+		})
+	}
 	// fmt.Println(doc)
 
-	// solnA := solveA(doc)
-	// fmt.Println("Solution A:", solnA)
+	if _, ok := doc.nodes[*startNode]; ok {
+		solnA := solveA(doc, *startNode, nodeIs(*endNode))
+		fmt.Println("Solution A:", solnA)
+	}
 
-	solnB := solveB(doc)
+	solnB := solveB(doc, isStart, isEnd)
 	fmt.Println("Solution B:", solnB)
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Difference layers down to the first all-zero layer, which is left out.
+// reachedZero is false when the differences ran out of terms first, so
+// the sequence can't be trusted to be a polynomial
+func getDiffLayers(seq []int) (layers [][]int, reachedZero bool) {
+	layer := seq
+	for len(layer) > 0 {
+		if isAllZeros(layer) {
+			return layers, true
+		}
+		layers = append(layers, layer)
+		layer = getPairwiseDiffs(layer)
+	}
+	return layers, false
+}
+
+// The first number of every difference layer, ie. Newton's forward
+// differences of the sequence at its first term
+func getForwardDiffs(seq []int) (leading []*big.Int, reachedZero bool) {
+	layers, reachedZero := getDiffLayers(seq)
+	leading = make([]*big.Int, len(layers))
+	for i, layer := range layers {
+		leading[i] = big.NewInt(int64(layer[0]))
+	}
+	return leading, reachedZero
+}
+
+// Predicts the number k steps after the last one, or -k steps before the
+// first one when k is negative, using
+//
+//	f(x) = sum over j of diff_j * C(x, j)
+//
+// where x is the index of the predicted number. C(x, j) is extended to
+// negative x as x(x-1)...(x-j+1) / j!. ok is false when the sequence
+// isn't known to be a polynomial, see getDiffLayers
+func extrapolate(seq []int, k int) (num *big.Int, ok bool) {
+	x := int64(k)
+	if k >= 0 {
+		x = int64(len(seq) - 1 + k)
+	}
+
+	diffs, ok := getForwardDiffs(seq)
+	num = new(big.Int)
+	binom := big.NewInt(1) // C(x, 0)
+	term := new(big.Int)
+
+	for j, diff := range diffs {
+		if j > 0 {
+			// C(x, j) = C(x, j-1) * (x-j+1) / j, the division is always exact
+			binom.Mul(binom, big.NewInt(x-int64(j)+1))
+			binom.Quo(binom, big.NewInt(int64(j)))
+		}
+		num.Add(num, term.Mul(diff, binom))
+	}
+
+	return num, ok
+}
+
+// Also returns the sequences whose extrapolation is a guess, since their
+// differences never reached zero
+func solveK(seqs [][]int, k int) (acc *big.Int, untrusted [][]int) {
+	acc = new(big.Int)
+	for _, seq := range seqs {
+		num, ok := extrapolate(seq, k)
+		if !ok {
+			untrusted = append(untrusted, seq)
+		}
+		acc.Add(acc, num)
+	}
+	return acc, untrusted
+}
+
+// Makes sure the closed form agrees with the difference pyramids
+func checkExtrapolation(seqs [][]int) error {
+	for _, seq := range seqs {
+		if next, _ := extrapolate(seq, 1); !next.IsInt64() || next.Int64() != int64(predictNextNum(seq)) {
+			return fmt.Errorf("%v: next is %d, want %d", seq, next, predictNextNum(seq))
+		}
+		if prev, _ := extrapolate(seq, -1); !prev.IsInt64() || prev.Int64() != int64(predictPrevNum(seq)) {
+			return fmt.Errorf("%v: previous is %d, want %d", seq, prev, predictPrevNum(seq))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type Polynomial struct {
+	// coeffs[i] is the coefficient of n^i, n being the 0-based index
+	coeffs []*big.Rat
+	// False when the differences ran out of terms before reaching a layer
+	// of zeros, so the sequence can't be trusted to be a polynomial
+	isPolynomial bool
+}
+
+// Expands sum over j of diff_j * C(n, j) into powers of n
+func fitPolynomial(seq []int) Polynomial {
+	diffs, reachedZero := getForwardDiffs(seq)
+
+	coeffs := make([]*big.Rat, max(len(diffs), 1))
+	for i := range coeffs {
+		coeffs[i] = new(big.Rat)
+	}
+
+	// Falling factorial n(n-1)...(n-j+1) in powers of n, and j!
+	falling := []*big.Int{big.NewInt(1)}
+	factorial := big.NewInt(1)
+
+	for j, diff := range diffs {
+		if j > 0 {
+			next := make([]*big.Int, len(falling)+1)
+			next[0] = new(big.Int)
+			for i := range falling {
+				next[i+1] = new(big.Int).Set(falling[i])
+				next[i].Sub(next[i], new(big.Int).Mul(falling[i], big.NewInt(int64(j-1))))
+			}
+			falling = next
+			factorial.Mul(factorial, big.NewInt(int64(j)))
+		}
+
+		for i, c := range falling {
+			term := new(big.Rat).SetFrac(new(big.Int).Mul(diff, c), factorial)
+			coeffs[i].Add(coeffs[i], term)
+		}
+	}
+
+	return Polynomial{coeffs, reachedZero}
+}
+
+// -1 for the zero polynomial
+func (p Polynomial) Degree() int {
+	for i := len(p.coeffs) - 1; i >= 0; i-- {
+		if p.coeffs[i].Sign() != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p Polynomial) String() string {
+	terms := []string{}
+	for i := p.Degree(); i >= 0; i-- {
+		c := p.coeffs[i]
+		if c.Sign() == 0 {
+			continue
+		}
+
+		sign := "+"
+		if c.Sign() < 0 {
+			sign = "-"
+		}
+		abs := new(big.Rat).Abs(c)
+
+		coeff := abs.RatString()
+		if !abs.IsInt() {
+			coeff = "(" + coeff + ")"
+		}
+		if i > 0 && abs.Cmp(big.NewRat(1, 1)) == 0 {
+			coeff = ""
+		}
+
+		switch i {
+		case 0:
+			terms = append(terms, sign, coeff)
+		case 1:
+			terms = append(terms, sign, coeff+"n")
+		default:
+			terms = append(terms, sign, fmt.Sprintf("%sn^%d", coeff, i))
+		}
+	}
+
+	if len(terms) == 0 {
+		return "f(n) = 0"
+	}
+	if terms[0] == "+" {
+		terms = terms[1:]
+	} else {
+		terms[1] = "-" + terms[1]
+		terms = terms[1:]
+	}
+	return "f(n) = " + strings.Join(terms, " ")
+}
+
+func fitReport(seqs [][]int) string {
+	var buff strings.Builder
+	for _, seq := range seqs {
+		p := fitPolynomial(seq)
+		if !p.isPolynomial {
+			fmt.Fprintf(&buff, "%v: non-polynomial, ran out of terms\n", seq)
+			continue
+		}
+		fmt.Fprintf(&buff, "%v: degree %d, %s\n", seq, p.Degree(), p)
+	}
+	return buff.String()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
@@ -120,12 +121,38 @@ func solveB(seqs [][]int) (acc int) {
 	return
 }
 
+var inputFile = flag.String("input", "day9/in.txt", "puzzle input")
+var steps = flag.Int("steps", 0, "also extrapolate this many steps ahead, or behind when negative")
+var fit = flag.Bool("fit", false, "print the polynomial generating each sequence")
+var check = flag.Bool("check", false, "check the closed form extrapolation against the difference pyramids")
+
 func main() {
-	seqs := parseInput("day9/in.txt")
+	flag.Parse()
+	seqs := parseInput(*inputFile)
 
 	solnA := solveA(seqs)
 	fmt.Println("Solution A:", solnA)
 
 	solnB := solveB(seqs)
 	fmt.Println("Solution B:", solnB)
+
+	if *steps != 0 {
+		soln, untrusted := solveK(seqs, *steps)
+		fmt.Printf("Solution %+d: %s\n", *steps, soln)
+		for _, seq := range untrusted {
+			fmt.Printf("Warning: %v is not a polynomial, its extrapolation is a guess\n", seq)
+		}
+	}
+
+	if *fit {
+		fmt.Print(fitReport(seqs))
+	}
+
+	if *check {
+		if err := checkExtrapolation(seqs); err != nil {
+			fmt.Println("Check failed:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Check passed")
+	}
 }